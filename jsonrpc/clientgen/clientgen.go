@@ -0,0 +1,272 @@
+// Package clientgen generates typed Go clients for JSONRPC services.
+//
+// The description of the service is read from an OpenRPC document, e.g. as returned by rpc.discover,
+// from the OpenAPI document of a service registered with humaadapter, or directly from the registry
+// of a Go package. The generated client has one method per RPC on top of a reqresp.Caller,
+// so it works on every transport.
+//
+// Example, in a program run with go:generate:
+//
+//	src, err := clientgen.GenerateFromSource(registry, clientgen.Config{Package: "calcclient"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	_ = os.WriteFile("calcclient/client_gen.go", src, 0o644)
+package clientgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ppipada/go-mcp-expt/jsonrpc/openrpc"
+)
+
+// Config configures the generated client.
+type Config struct {
+	// Package is the name of the package of the generated file.
+	Package string
+	// ClientName is the name of the generated client type. The default is "Client".
+	ClientName string
+}
+
+// schema is the subset of JSON schema used to generate types.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 schemaTypes        `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Description          string             `json:"description"`
+	Enum                 []any              `json:"enum"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	OneOf                []*schema          `json:"oneOf"`
+	AnyOf                []*schema          `json:"anyOf"`
+}
+
+// schemaTypes holds the type of a schema, which is either a single type or a list of types.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var types []string
+		if err := json.Unmarshal(data, &types); err != nil {
+			return err
+		}
+		*t = types
+		return nil
+	}
+	var typ string
+	if err := json.Unmarshal(data, &typ); err != nil {
+		return err
+	}
+	*t = schemaTypes{typ}
+	return nil
+}
+
+// method is an RPC to generate a client method for.
+type method struct {
+	name         string
+	notification bool
+	// Schema of the params, nil if the method has no params.
+	params         *schema
+	paramsRequired bool
+	result         *schema
+	resultRequired bool
+}
+
+// service is the description of a service, read from an OpenRPC or OpenAPI document.
+type service struct {
+	methods []method
+	// Component schemas, by name.
+	schemas map[string]*schema
+}
+
+// Generate generates the source of a client for the service described by doc, which is an OpenRPC
+// or OpenAPI document in JSON.
+func Generate(doc []byte, cfg Config) ([]byte, error) {
+	var header struct {
+		OpenRPC string `json:"openrpc"`
+		OpenAPI string `json:"openapi"`
+	}
+	if err := json.Unmarshal(doc, &header); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	var svc *service
+	var err error
+	switch {
+	case header.OpenRPC != "":
+		svc, err = parseOpenRPC(doc)
+	case header.OpenAPI != "":
+		svc, err = parseOpenAPI(doc)
+	default:
+		return nil, errors.New("document is neither an OpenRPC nor an OpenAPI document")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return generate(svc, cfg)
+}
+
+// GenerateFromSource generates the source of a client for the handlers of source,
+// e.g. a reqresp.Registry or reqresp.Router.
+func GenerateFromSource(source openrpc.Source, cfg Config) ([]byte, error) {
+	doc, err := json.Marshal(openrpc.Generate(source))
+	if err != nil {
+		return nil, err
+	}
+	return Generate(doc, cfg)
+}
+
+func parseOpenRPC(data []byte) (*service, error) {
+	var doc struct {
+		Methods []struct {
+			Name   string `json:"name"`
+			Params []struct {
+				Name     string  `json:"name"`
+				Required bool    `json:"required"`
+				Schema   *schema `json:"schema"`
+			} `json:"params"`
+			Result *struct {
+				Required bool    `json:"required"`
+				Schema   *schema `json:"schema"`
+			} `json:"result"`
+			ParamStructure string `json:"paramStructure"`
+		} `json:"methods"`
+		Components struct {
+			Schemas map[string]*schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenRPC document: %w", err)
+	}
+
+	svc := &service{schemas: doc.Components.Schemas}
+	for _, m := range doc.Methods {
+		if m.Name == openrpc.DiscoverMethod {
+			continue
+		}
+		method := method{name: m.Name, notification: m.Result == nil}
+		if m.Result != nil {
+			method.result = m.Result.Schema
+			method.resultRequired = m.Result.Required
+		}
+		switch {
+		case len(m.Params) == 1 && m.Params[0].Name == "params" && m.ParamStructure == "":
+			// Params that are not an object are described as a whole.
+			method.params = m.Params[0].Schema
+			method.paramsRequired = m.Params[0].Required
+		case len(m.Params) > 0:
+			if m.ParamStructure == openrpc.ParamStructureByPosition {
+				return nil, fmt.Errorf(
+					"method %s: params by position only are not supported",
+					m.Name,
+				)
+			}
+			params := &schema{
+				Type:       schemaTypes{"object"},
+				Properties: make(map[string]*schema),
+			}
+			for _, p := range m.Params {
+				params.Properties[p.Name] = p.Schema
+				if p.Required {
+					params.Required = append(params.Required, p.Name)
+				}
+			}
+			method.params = params
+			method.paramsRequired = true
+		}
+		svc.methods = append(svc.methods, method)
+	}
+	return svc, nil
+}
+
+// parseOpenAPI reads the methods from the request and response schemas added by humaadapter.
+// Request schemas are the ones with a "method" property with a single enum value. Requests without
+// an "id" property are notifications. The result of a method is in the success response schema
+// with the same name prefix.
+func parseOpenAPI(data []byte) (*service, error) {
+	var doc struct {
+		Components struct {
+			Schemas map[string]*schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	svc := &service{schemas: doc.Components.Schemas}
+	for key, s := range doc.Components.Schemas {
+		methodProp := s.Properties["method"]
+		if methodProp == nil || len(methodProp.Enum) != 1 || !strings.HasSuffix(key, "Request") {
+			continue
+		}
+		name, ok := methodProp.Enum[0].(string)
+		if !ok {
+			continue
+		}
+		_, hasID := s.Properties["id"]
+		method := method{name: name, notification: !hasID}
+		if params := s.Properties["params"]; params != nil {
+			// Drop the alternative array schema accepting params by position.
+			if len(params.OneOf) == 2 && isTypeOf(params.OneOf[1], "array") &&
+				params.OneOf[1].Items == nil {
+				params = params.OneOf[0]
+			}
+			// Params of an empty struct type are not sent.
+			if !isEmptyObject(svc.resolve(params)) {
+				method.params = params
+				method.paramsRequired = slices.Contains(s.Required, "params")
+			}
+		}
+		if !method.notification {
+			success := doc.Components.Schemas[strings.TrimSuffix(key, "Request")+"SuccessResponse"]
+			if success == nil || success.Properties["result"] == nil {
+				return nil, fmt.Errorf("method %s: no success response schema", name)
+			}
+			method.result = success.Properties["result"]
+			method.resultRequired = slices.Contains(success.Required, "result")
+		}
+		svc.methods = append(svc.methods, method)
+	}
+	slices.SortFunc(svc.methods, func(a, b method) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return svc, nil
+}
+
+// resolve returns the component schema s refers to, or s if it is not a reference.
+func (svc *service) resolve(s *schema) *schema {
+	if s.Ref == "" {
+		return s
+	}
+	if component := svc.schemas[componentName(s.Ref)]; component != nil {
+		return component
+	}
+	return s
+}
+
+// componentName returns the name of the component schema at ref.
+// OpenRPC and OpenAPI documents both keep them under #/components/schemas/.
+func componentName(ref string) string {
+	if name, ok := strings.CutPrefix(ref, openrpc.SchemaRefPrefix); ok {
+		return name
+	}
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// isEmptyObject reports whether s is an object without properties, which accepts no others.
+func isEmptyObject(s *schema) bool {
+	return isTypeOf(s, "object") && len(s.Properties) == 0 &&
+		string(bytes.TrimSpace(s.AdditionalProperties)) == "false"
+}
+
+// isTypeOf reports whether s is of type typ, possibly nullable.
+func isTypeOf(s *schema, typ string) bool {
+	return slices.Contains(s.Type, typ)
+}
@@ -0,0 +1,165 @@
+package clientgen
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/ppipada/go-mcp-expt/jsonrpc/clientgen/internal/exampleclient"
+	"github.com/ppipada/go-mcp-expt/jsonrpc/humaadapter"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+var update = flag.Bool("update", false, "update the generated example client")
+
+const exampleClientPath = "internal/exampleclient/client_gen.go"
+
+type AddParams struct {
+	A int `json:"a" jsonrpc:"pos=0"`
+	B int `json:"b" jsonrpc:"pos=1"`
+}
+
+type AddResult struct {
+	Sum int `json:"sum"`
+}
+
+type Tool struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ListToolsResult struct {
+	Tools      []Tool  `json:"tools"`
+	NextCursor *string `json:"nextCursor,omitempty"`
+}
+
+type LogParams struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func getRegistry(logged chan<- LogParams) *jsonrpcReqResp.Registry {
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[AddParams, AddResult]{
+		Endpoint: func(ctx context.Context, params AddParams) (AddResult, error) {
+			return AddResult{Sum: params.A + params.B}, nil
+		},
+	})
+	registry.RegisterMethod("tools/list", &jsonrpcReqResp.MethodHandler[*struct{}, ListToolsResult]{
+		Endpoint: func(ctx context.Context, _ *struct{}) (ListToolsResult, error) {
+			return ListToolsResult{Tools: []Tool{{Name: "add", Tags: []string{"math"}}}}, nil
+		},
+	})
+	registry.RegisterMethod("sum", &jsonrpcReqResp.MethodHandler[[]int, *int]{
+		Endpoint: func(ctx context.Context, params []int) (*int, error) {
+			sum := 0
+			for _, v := range params {
+				sum += v
+			}
+			return &sum, nil
+		},
+	})
+	registry.RegisterNotification("log", &jsonrpcReqResp.NotificationHandler[LogParams]{
+		Endpoint: func(ctx context.Context, params LogParams) error {
+			logged <- params
+			return nil
+		},
+	})
+	return registry
+}
+
+func TestGenerateFromSource(t *testing.T) {
+	src, err := GenerateFromSource(getRegistry(nil), Config{Package: "exampleclient"})
+	if err != nil {
+		t.Fatalf("GenerateFromSource returned error: %v", err)
+	}
+	if *update {
+		if err := os.WriteFile(exampleClientPath, src, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(exampleClientPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != string(want) {
+		t.Errorf("Generated client differs from %s, run the tests with -update:\n%s", exampleClientPath, src)
+	}
+}
+
+// peerCaller is a Caller dispatching messages to a handler through a Peer.
+type peerCaller struct {
+	peer *jsonrpcReqResp.Peer
+}
+
+func (c *peerCaller) SendContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return c.peer.HandleMessage(ctx, msg)
+}
+
+func TestGeneratedClient(t *testing.T) {
+	logged := make(chan LogParams, 1)
+	handler := jsonrpcReqResp.NewBatchRequestHandler(jsonrpcReqResp.WithRegistry(getRegistry(logged)))
+	client := exampleclient.NewClient(&peerCaller{peer: jsonrpcReqResp.NewPeer(handler, nil)})
+
+	sum, err := client.Add(t.Context(), exampleclient.AddParams{A: 2, B: 3})
+	if err != nil || sum.Sum != 5 {
+		t.Errorf("Expected sum 5, got %+v, %v", sum, err)
+	}
+	tools, err := client.ToolsList(t.Context())
+	if err != nil || len(tools.Tools) != 1 || tools.Tools[0].Name != "add" {
+		t.Errorf("Unexpected tools: %+v, %v", tools, err)
+	}
+	total, err := client.Sum(t.Context(), []int64{1, 2, 3})
+	if err != nil || total == nil || *total != 6 {
+		t.Errorf("Expected total 6, got %v, %v", total, err)
+	}
+	if err := client.Log(t.Context(), exampleclient.LogParams{Level: "info", Message: "hi"}); err != nil {
+		t.Fatalf("Log returned error: %v", err)
+	}
+	if got := <-logged; got.Message != "hi" {
+		t.Errorf("Unexpected notification: %+v", got)
+	}
+}
+
+func TestGenerateFromOpenAPI(t *testing.T) {
+	_, api := humatest.New(t)
+	humaadapter.RegisterRegistry(api, humaadapter.GetDefaultOperation(), getRegistry(nil))
+	doc, err := json.Marshal(api.OpenAPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := Generate(doc, Config{Package: "calc", ClientName: "Calc"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	for _, want := range []string{
+		"package calc",
+		"func NewCalc(caller jsonrpcReqResp.Caller) *Calc {",
+		"func (c *Calc) Add(ctx context.Context, params AddParams) (AddResult, error) {",
+		"func (c *Calc) ToolsList(ctx context.Context) (ListToolsResult, error) {",
+		"func (c *Calc) Sum(ctx context.Context, params []int64) (*int64, error) {",
+		"func (c *Calc) Log(ctx context.Context, params LogParams) error {",
+		"Tools []Tool `json:\"tools\"`",
+		"Annotations map[string]string `json:\"annotations,omitempty\"`",
+	} {
+		if !strings.Contains(strings.Join(strings.Fields(string(src)), " "), want) {
+			t.Errorf("Expected generated client to contain %q, got:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := Generate([]byte(`{"swagger":"2.0"}`), Config{Package: "x"}); err == nil {
+		t.Errorf("Expected unknown documents to be rejected")
+	}
+	if _, err := Generate([]byte(`{"openrpc":"1.3.2","methods":[]}`), Config{}); err == nil {
+		t.Errorf("Expected missing package name to be rejected")
+	}
+}
@@ -0,0 +1,290 @@
+package clientgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// emitter writes the Go source of a client.
+type emitter struct {
+	svc *service
+	// Declarations of the named types, in order of declaration.
+	decls []string
+	// Names of the declared types, by component name for components.
+	declared   map[string]bool
+	components map[string]string
+}
+
+func generate(svc *service, cfg Config) ([]byte, error) {
+	if cfg.Package == "" {
+		return nil, errors.New("package name is required")
+	}
+	clientName := cfg.ClientName
+	if clientName == "" {
+		clientName = "Client"
+	}
+	e := &emitter{
+		svc:        svc,
+		declared:   map[string]bool{clientName: true, "New" + clientName: true},
+		components: make(map[string]string),
+	}
+
+	var methods bytes.Buffer
+	goNames := make(map[string]bool)
+	for _, m := range svc.methods {
+		goName := uniqueName(exportedName(m.name), goNames)
+		goNames[goName] = true
+		e.emitMethod(&methods, clientName, goName, m)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by clientgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", cfg.Package)
+	src.WriteString("import (\n\t\"context\"\n")
+	if strings.Contains(strings.Join(e.decls, "")+methods.String(), "json.") {
+		src.WriteString("\t\"encoding/json\"\n")
+	}
+	src.WriteString("\n\tjsonrpcReqResp \"github.com/ppipada/go-mcp-expt/jsonrpc/reqresp\"\n)\n\n")
+	fmt.Fprintf(
+		&src,
+		"// %s is a typed client, sending requests with a jsonrpcReqResp.Caller.\n",
+		clientName,
+	)
+	fmt.Fprintf(&src, "type %s struct {\n\tcaller jsonrpcReqResp.Caller\n}\n\n", clientName)
+	fmt.Fprintf(&src, "// New%s creates a client sending requests with caller.\n", clientName)
+	fmt.Fprintf(&src, "func New%[1]s(caller jsonrpcReqResp.Caller) *%[1]s {\n", clientName)
+	fmt.Fprintf(&src, "\treturn &%s{caller: caller}\n}\n\n", clientName)
+	src.Write(methods.Bytes())
+	for _, decl := range e.decls {
+		src.WriteString(decl)
+	}
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w", err)
+	}
+	return out, nil
+}
+
+func (e *emitter) emitMethod(w *bytes.Buffer, clientName, goName string, m method) {
+	paramsType := "*struct{}"
+	paramsArg := ""
+	paramsValue := "nil"
+	if m.params != nil {
+		paramsType = e.goType(m.params, goName+"Params")
+		if !m.paramsRequired {
+			paramsType = nullable(paramsType)
+		}
+		paramsArg = ", params " + paramsType
+		paramsValue = "params"
+	}
+
+	if m.notification {
+		fmt.Fprintf(w, "// %s sends the %q notification.\n", goName, m.name)
+		fmt.Fprintf(w, "func (c *%s) %s(ctx context.Context%s) error {\n",
+			clientName, goName, paramsArg)
+		fmt.Fprintf(w, "\treturn jsonrpcReqResp.Notify[%s](ctx, c.caller, %q, %s)\n}\n\n",
+			paramsType, m.name, paramsValue)
+		return
+	}
+
+	resultType := e.goType(m.result, goName+"Result")
+	if !m.resultRequired {
+		resultType = nullable(resultType)
+	}
+	fmt.Fprintf(w, "// %s calls the %q method.\n", goName, m.name)
+	fmt.Fprintf(w, "func (c *%s) %s(ctx context.Context%s) (%s, error) {\n",
+		clientName, goName, paramsArg, resultType)
+	fmt.Fprintf(w, "\treturn jsonrpcReqResp.Call[%s, %s](ctx, c.caller, %q, %s)\n}\n\n",
+		paramsType, resultType, m.name, paramsValue)
+}
+
+// goType returns the Go type for s. Objects with properties are declared as named types,
+// using the component name for references and hint otherwise.
+func (e *emitter) goType(s *schema, hint string) string {
+	if s == nil {
+		return "any"
+	}
+	if s.Ref != "" {
+		return e.componentType(s.Ref)
+	}
+	if alternatives := append(slices.Clone(s.OneOf), s.AnyOf...); len(alternatives) > 0 {
+		// A single alternative besides null is a nullable type, others can not be typed.
+		nonNull := slices.DeleteFunc(alternatives, func(a *schema) bool {
+			return len(a.Type) == 1 && a.Type[0] == "null"
+		})
+		if len(nonNull) == 1 {
+			return nullable(e.goType(nonNull[0], hint))
+		}
+		return "any"
+	}
+
+	typ := ""
+	isNullable := s.Nullable
+	for _, t := range s.Type {
+		if t == "null" {
+			isNullable = true
+		} else if typ == "" {
+			typ = t
+		} else {
+			// Multiple types can not be typed.
+			return "any"
+		}
+	}
+
+	var goType string
+	switch typ {
+	case "object":
+		goType = e.objectType(s, hint)
+	case "array":
+		goType = "[]" + e.goType(s.Items, hint+"Item")
+	case "string":
+		goType = "string"
+	case "integer":
+		goType = "int64"
+		if s.Format == "int32" {
+			goType = "int32"
+		}
+	case "number":
+		goType = "float64"
+		if s.Format == "float" {
+			goType = "float32"
+		}
+	case "boolean":
+		goType = "bool"
+	default:
+		return "any"
+	}
+	if isNullable {
+		return nullable(goType)
+	}
+	return goType
+}
+
+func (e *emitter) objectType(s *schema, hint string) string {
+	if len(s.Properties) == 0 {
+		var additional schema
+		if len(s.AdditionalProperties) > 0 && s.AdditionalProperties[0] == '{' &&
+			json.Unmarshal(s.AdditionalProperties, &additional) == nil {
+			return "map[string]" + e.goType(&additional, hint+"Value")
+		}
+		return "map[string]any"
+	}
+	name := uniqueName(exportedName(hint), e.declared)
+	e.declared[name] = true
+	e.declareStruct(name, s)
+	return name
+}
+
+// componentType returns the type declared for the component schema at ref.
+func (e *emitter) componentType(ref string) string {
+	component := componentName(ref)
+	if name, ok := e.components[component]; ok {
+		return name
+	}
+	s := e.svc.schemas[component]
+	if s == nil {
+		return "json.RawMessage"
+	}
+	if len(s.Properties) == 0 || !isTypeOf(s, "object") {
+		// Only objects are declared, other component types are used inline.
+		name := e.goType(s, component)
+		e.components[component] = name
+		return name
+	}
+	name := uniqueName(exportedName(component), e.declared)
+	e.declared[name] = true
+	// Register before declaring, so recursive references resolve.
+	e.components[component] = name
+	e.declareStruct(name, s)
+	return name
+}
+
+func (e *emitter) declareStruct(name string, s *schema) {
+	props := make([]string, 0, len(s.Properties))
+	for prop := range s.Properties {
+		props = append(props, prop)
+	}
+	slices.Sort(props)
+
+	// Field types are resolved first, as they may declare more types.
+	fieldNames := make(map[string]bool)
+	var fields strings.Builder
+	for _, prop := range props {
+		ps := s.Properties[prop]
+		fieldName := uniqueName(exportedName(prop), fieldNames)
+		fieldNames[fieldName] = true
+		fieldType := e.goType(ps, name+fieldName)
+		tag := prop
+		if !slices.Contains(s.Required, prop) {
+			fieldType = nullable(fieldType)
+			tag += ",omitempty"
+		}
+		if ps != nil && ps.Description != "" {
+			for line := range strings.SplitSeq(ps.Description, "\n") {
+				fmt.Fprintf(&fields, "\t// %s\n", line)
+			}
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%s`\n", fieldName, fieldType, strconv.Quote(tag))
+	}
+
+	var decl strings.Builder
+	if s.Description != "" {
+		for line := range strings.SplitSeq(s.Description, "\n") {
+			fmt.Fprintf(&decl, "// %s\n", line)
+		}
+	}
+	fmt.Fprintf(&decl, "type %s struct {\n%s}\n\n", name, fields.String())
+	e.decls = append(e.decls, decl.String())
+}
+
+// uniqueName returns name, with a number appended if it is already taken.
+func uniqueName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		if candidate := name + strconv.Itoa(i); !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// nullable returns the type holding goType or null.
+func nullable(goType string) string {
+	if goType == "any" || strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") ||
+		strings.HasPrefix(goType, "map[") || goType == "json.RawMessage" {
+		return goType
+	}
+	return "*" + goType
+}
+
+// exportedName converts a method, property or schema name to an exported Go identifier,
+// e.g. "tools/list" to "ToolsList".
+func exportedName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	out := b.String()
+	if out == "" || !unicode.IsLetter([]rune(out)[0]) {
+		out = "X" + out
+	}
+	return out
+}
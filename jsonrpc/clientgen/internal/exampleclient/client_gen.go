@@ -0,0 +1,65 @@
+// Code generated by clientgen. DO NOT EDIT.
+
+package exampleclient
+
+import (
+	"context"
+
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// Client is a typed client, sending requests with a jsonrpcReqResp.Caller.
+type Client struct {
+	caller jsonrpcReqResp.Caller
+}
+
+// NewClient creates a client sending requests with caller.
+func NewClient(caller jsonrpcReqResp.Caller) *Client {
+	return &Client{caller: caller}
+}
+
+// Add calls the "add" method.
+func (c *Client) Add(ctx context.Context, params AddParams) (AddResult, error) {
+	return jsonrpcReqResp.Call[AddParams, AddResult](ctx, c.caller, "add", params)
+}
+
+// Log sends the "log" notification.
+func (c *Client) Log(ctx context.Context, params LogParams) error {
+	return jsonrpcReqResp.Notify[LogParams](ctx, c.caller, "log", params)
+}
+
+// Sum calls the "sum" method.
+func (c *Client) Sum(ctx context.Context, params []int64) (*int64, error) {
+	return jsonrpcReqResp.Call[[]int64, *int64](ctx, c.caller, "sum", params)
+}
+
+// ToolsList calls the "tools/list" method.
+func (c *Client) ToolsList(ctx context.Context) (ListToolsResult, error) {
+	return jsonrpcReqResp.Call[*struct{}, ListToolsResult](ctx, c.caller, "tools/list", nil)
+}
+
+type AddParams struct {
+	A int64 `json:"a"`
+	B int64 `json:"b"`
+}
+
+type AddResult struct {
+	Sum int64 `json:"sum"`
+}
+
+type LogParams struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+type Tool struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Name        string            `json:"name"`
+	Tags        []string          `json:"tags"`
+}
+
+type ListToolsResult struct {
+	NextCursor *string `json:"nextCursor,omitempty"`
+	Tools      []Tool  `json:"tools"`
+}
@@ -0,0 +1,56 @@
+// Command clientgen generates a typed Go client from an OpenRPC or OpenAPI document.
+//
+// Usage:
+//
+//	clientgen -package calcclient -in openrpc.json -out calcclient/client_gen.go
+//
+// The OpenRPC document can be fetched with the rpc.discover method of a server, and the OpenAPI
+// document from the /openapi.json endpoint of a server registered with humaadapter.
+// To generate a client from the registry of a Go package, call clientgen.GenerateFromSource in a
+// program run with go:generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ppipada/go-mcp-expt/jsonrpc/clientgen"
+)
+
+func main() {
+	in := flag.String("in", "-", "path of the OpenRPC or OpenAPI document, - for stdin")
+	out := flag.String("out", "-", "path of the generated file, - for stdout")
+	pkg := flag.String("package", "", "package name of the generated file")
+	client := flag.String("client", "Client", "name of the generated client type")
+	flag.Parse()
+
+	if err := run(*in, *out, clientgen.Config{Package: *pkg, ClientName: *client}); err != nil {
+		fmt.Fprintln(os.Stderr, "clientgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string, cfg clientgen.Config) error {
+	var doc []byte
+	var err error
+	if in == "-" {
+		doc, err = io.ReadAll(os.Stdin)
+	} else {
+		doc, err = os.ReadFile(in)
+	}
+	if err != nil {
+		return err
+	}
+
+	src, err := clientgen.Generate(doc, cfg)
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o600)
+}
@@ -0,0 +1,75 @@
+package humaadapter
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// defaultMaxBodyBytes is the body limit of huma operations that do not set one.
+const defaultMaxBodyBytes = 1024 * 1024
+
+type contextKey string
+
+const ctxKeyBody contextKey = "body"
+
+// BufferBodyMiddleware returns an operation middleware keeping a copy of request bodies of up to
+// maxBytes in the request context, so that errors can be reported with the ID of the request.
+// Values <= 0 use the default body limit of huma.
+func BufferBodyMiddleware(maxBytes int64) func(huma.Context, func(huma.Context)) {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBodyBytes
+	}
+	return func(ctx huma.Context, next func(huma.Context)) {
+		reader := ctx.BodyReader()
+		if reader == nil {
+			next(ctx)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(reader, maxBytes))
+		if err != nil {
+			// Let huma report the error when it reads the body.
+			next(&bufferedBodyContext{
+				humaContext: ctx,
+				body:        io.MultiReader(bytes.NewReader(body), errReader{err}),
+			})
+			return
+		}
+		if int64(len(body)) < maxBytes {
+			ctx = huma.WithValue(ctx, ctxKeyBody, body)
+		}
+		next(&bufferedBodyContext{
+			humaContext: ctx,
+			body:        io.MultiReader(bytes.NewReader(body), reader),
+		})
+	}
+}
+
+// getBufferedBody returns the request body kept by BufferBodyMiddleware, nil if there is none.
+func getBufferedBody(ctx context.Context) []byte {
+	body, _ := ctx.Value(ctxKeyBody).([]byte)
+	return body
+}
+
+// humaContext is embedded under another name, as huma.Context has a Context method.
+type humaContext = huma.Context
+
+// bufferedBodyContext replays the body read by BufferBodyMiddleware.
+type bufferedBodyContext struct {
+	humaContext
+	body io.Reader
+}
+
+func (c *bufferedBodyContext) BodyReader() io.Reader {
+	return c.body
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -1,7 +1,15 @@
 package humaadapter
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
@@ -25,6 +33,9 @@ func (e *ResponseStatusError) GetStatus() int {
 
 func (e ResponseStatusError) Schema(r huma.Registry) *huma.Schema {
 	errorObjectSchema := r.Schema(reflect.TypeOf(e.Response.Error), true, "")
+	idSchema := IntStringSchema()
+	idSchema.Description = "Request identifier. Compulsory for method responses. " +
+		"This MUST be null to the client in case of parse errors etc."
 
 	responseObjectSchema := &huma.Schema{
 		Type:     huma.TypeObject,
@@ -35,16 +46,379 @@ func (e ResponseStatusError) Schema(r huma.Registry) *huma.Schema {
 				Enum:        []any{"2.0"},
 				Description: "JSON-RPC version, must be '2.0'",
 			},
-			"id": {
-				Description: "Request identifier. Compulsory for method responses. This MUST be null to the client in case of parse errors etc.",
-				OneOf: []*huma.Schema{
-					{Type: huma.TypeInteger},
-					{Type: huma.TypeString},
-				},
-			},
+			"id":    idSchema,
 			"error": errorObjectSchema,
 		},
 	}
 
 	return responseObjectSchema
 }
+
+// ErrorHandler converts the errors of an operation to a status error, like huma.NewErrorWithContext.
+// The context is nil when the handler is called outside of a request.
+type ErrorHandler func(ctx huma.Context, status int, message string, errs ...error) huma.StatusError
+
+// RegisterWithErrorHandler registers op like huma.Register, converting the errors written for op by
+// huma, e.g. for a body not matching its schema, or returned by h as errors that are not a
+// huma.StatusError, with handler instead of huma.NewErrorWithContext.
+// The errors of other operations are left to huma, so several JSONRPC operations and other operations
+// can be registered on the same API. The handler is also used for the default error response
+// documented for op.
+func RegisterWithErrorHandler[I, O any](
+	api huma.API,
+	op huma.Operation,
+	handler ErrorHandler,
+	h func(context.Context, *I) (*O, error),
+) {
+	// Huma documents the errors of operations with the type returned by huma.NewError.
+	if op.Responses == nil {
+		op.Responses = make(map[string]*huma.Response)
+	}
+	if _, ok := op.Responses["default"]; !ok {
+		errType := reflect.TypeOf(handler(nil, 0, ""))
+		if errType.Kind() == reflect.Pointer {
+			errType = errType.Elem()
+		}
+		op.Responses["default"] = &huma.Response{
+			Description: "Error",
+			Content: map[string]*huma.MediaType{
+				"application/json": {
+					Schema: api.OpenAPI().Components.Schemas.Schema(errType, true, errType.Name()),
+				},
+			},
+		}
+	}
+	registerWithErrorHandler(api, op, handler, h)
+}
+
+// registerWithErrorHandler is RegisterWithErrorHandler without documenting the errors.
+//
+// Huma writes its errors with the huma.StatusError created by huma.NewErrorWithContext, through the
+// transformers of the API. The errors of op are converted in place of the transformers, from the
+// details of the *huma.ErrorModel created by huma, or from the errors it unwraps to if
+// huma.NewErrorWithContext is replaced.
+func registerWithErrorHandler[I, O any](
+	api huma.API,
+	op huma.Operation,
+	handler ErrorHandler,
+	h func(context.Context, *I) (*O, error),
+) {
+	op.Middlewares = append(op.Middlewares, func(ctx huma.Context, next func(huma.Context)) {
+		c := &errorContext{humaContext: ctx, handler: handler}
+		c.ctx = context.WithValue(ctx.Context(), ctxKeyErrorContext, c)
+		next(c)
+	})
+	huma.Register(errorAPI{api}, op, func(ctx context.Context, input *I) (*O, error) {
+		output, err := h(ctx, input)
+		if c, ok := ctx.Value(ctxKeyErrorContext).(*errorContext); ok && err != nil {
+			return nil, c.handlerError(err)
+		}
+		return output, err
+	})
+}
+
+// errorAPI is the API the operations of registerWithErrorHandler are registered with.
+type errorAPI struct {
+	huma.API
+}
+
+// Transform converts the errors written for the operations of registerWithErrorHandler, then runs the
+// transformers of the API.
+func (a errorAPI) Transform(ctx huma.Context, status string, v any) (any, error) {
+	if c, ok := ctx.(*errorContext); ok {
+		if converted := c.convert(v); converted != nil {
+			v = converted
+			status = strconv.Itoa(converted.GetStatus())
+			ct, err := a.Negotiate(ctx.Header("Accept"))
+			if err != nil {
+				ct = "application/json"
+			}
+			if f, ok := converted.(huma.ContentTypeFilter); ok {
+				ct = f.ContentType(ct)
+			}
+			ctx.SetHeader("Content-Type", ct)
+		}
+	}
+	return a.API.Transform(ctx, status, v)
+}
+
+const ctxKeyErrorContext contextKey = "errorContext"
+
+// errorContext is the huma.Context of a request to an operation of registerWithErrorHandler.
+type errorContext struct {
+	humaContext
+	ctx     context.Context
+	handler ErrorHandler
+	// status of the converted error, which replaces the status of the huma error.
+	status int
+	// handled is set when the operation handler returns an error, which is written as is.
+	handled bool
+}
+
+func (c *errorContext) Context() context.Context {
+	return c.ctx
+}
+
+func (c *errorContext) SetStatus(status int) {
+	if c.status != 0 {
+		status = c.status
+	}
+	c.humaContext.SetStatus(status)
+}
+
+// handlerError returns the error to return to huma for err, returned by the operation handler.
+func (c *errorContext) handlerError(err error) error {
+	c.handled = true
+	var se huma.StatusError
+	if errors.As(err, &se) {
+		return err
+	}
+	return c.handler(c, http.StatusInternalServerError, "unexpected error occurred", err)
+}
+
+// convert returns the error of the operation for v, a value written by huma, nil if v is not an error
+// to convert.
+func (c *errorContext) convert(v any) huma.StatusError {
+	se, ok := v.(huma.StatusError)
+	if !ok || c.status != 0 {
+		return nil
+	}
+	if c.handled {
+		// The error returned by the operation handler.
+		c.handled = false
+		return nil
+	}
+	message := se.Error()
+	var errs []error
+	switch e := se.(type) {
+	case *huma.ErrorModel:
+		message = e.Detail
+		for _, detail := range e.Errors {
+			errs = append(errs, detail)
+		}
+	case interface{ Unwrap() []error }:
+		errs = e.Unwrap()
+	default:
+		errs = []error{se}
+	}
+	converted := c.handler(c, se.GetStatus(), message, errs...)
+	c.status = converted.GetStatus()
+	return converted
+}
+
+// ErrorData is the data of the JSONRPC errors converted from huma errors.
+type ErrorData struct {
+	// BatchIndex is the position of the invalid item in a batch request, if known.
+	BatchIndex *int          `json:"batchIndex,omitempty"`
+	Errors     []ErrorDetail `json:"errors,omitempty"`
+}
+
+// ErrorDetail is one of the errors reported by huma.
+type ErrorDetail struct {
+	// Location of the error, e.g. "body[1].params.a".
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Error codes from the least to the most specific about the whole request. When huma reports
+// several errors, the JSONRPC error gets the code of the most specific one.
+var errorCodeRank = []jsonrpcReqResp.JSONRPCErrorCode{
+	jsonrpcReqResp.InternalError,
+	jsonrpcReqResp.InvalidParamsError,
+	jsonrpcReqResp.MethodNotFoundError,
+	jsonrpcReqResp.InvalidRequestError,
+	jsonrpcReqResp.ParseError,
+}
+
+func getErrorHandler(methodExists func(methodName string) bool) ErrorHandler {
+	return func(ctx huma.Context, status int, message string, errs ...error) huma.StatusError {
+		var body []byte
+		if ctx != nil {
+			body = getBufferedBody(ctx.Context())
+		}
+		c := &errorClassifier{methodExists: methodExists}
+		c.items, c.isBatch = splitBatch(body)
+		jsonRPCError, id := c.classify(status, errs)
+
+		// The error is sent as a JSONRPC response, not as an HTTP error.
+		return &ResponseStatusError{
+			status: http.StatusOK,
+			Response: jsonrpcReqResp.Response[any]{
+				JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+				ID:      id,
+				Error:   jsonRPCError,
+			},
+		}
+	}
+}
+
+// errorClassifier converts the errors huma reports for a request to a JSONRPC error.
+type errorClassifier struct {
+	methodExists func(methodName string) bool
+	// Items of the request body, nil if the body is unknown or not JSON.
+	items   []json.RawMessage
+	isBatch bool
+}
+
+// classify returns the JSONRPC error for errs, and the ID of the request they are about, if known.
+func (c *errorClassifier) classify(
+	status int,
+	errs []error,
+) (*jsonrpcReqResp.JSONRPCError, *jsonrpcReqResp.RequestID) {
+	code := jsonrpcReqResp.InternalError
+	if status >= 400 && status < 500 {
+		code = jsonrpcReqResp.InvalidRequestError
+	}
+	var (
+		data       ErrorData
+		index      = c.singleIndex()
+		methodName string
+		found      *jsonrpcReqResp.JSONRPCError
+		classified bool
+	)
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var jsonRPCError jsonrpcReqResp.JSONRPCError
+		detailer, isDetail := err.(huma.ErrorDetailer)
+		switch {
+		case isDetail:
+			d := detailer.ErrorDetail()
+			data.Errors = append(data.Errors, ErrorDetail{Location: d.Location, Message: d.Message})
+			detailCode, detailIndex, detailMethod := c.classifyDetail(status, d)
+			if !classified || rank(detailCode) > rank(code) {
+				code, index, methodName = detailCode, detailIndex, detailMethod
+				classified = true
+			}
+		case errors.As(err, &jsonRPCError):
+			found = &jsonRPCError
+		default:
+			data.Errors = append(data.Errors, ErrorDetail{Message: err.Error()})
+		}
+	}
+
+	id := c.requestID(index)
+	if found != nil {
+		return found, id
+	}
+	if c.isBatch && index >= 0 {
+		data.BatchIndex = &index
+	}
+	jsonRPCError := &jsonrpcReqResp.JSONRPCError{
+		Code:    code,
+		Message: jsonrpcReqResp.GetDefaultErrorMessage(code),
+	}
+	if code == jsonrpcReqResp.MethodNotFoundError {
+		jsonRPCError.Message += ": " + methodName
+	}
+	if data.BatchIndex != nil || len(data.Errors) > 0 {
+		jsonRPCError.Data = data
+	}
+	return jsonRPCError, id
+}
+
+// classifyDetail returns the error code for an error at a location of the request body, with the
+// position of the item in a batch, -1 if unknown, and the method name for unknown methods.
+func (c *errorClassifier) classifyDetail(
+	status int,
+	d *huma.ErrorDetail,
+) (code jsonrpcReqResp.JSONRPCErrorCode, index int, methodName string) {
+	index = c.singleIndex()
+	path, ok := strings.CutPrefix(d.Location, "body")
+	if !ok || (path != "" && path[0] != '.' && path[0] != '[') {
+		// Errors outside of the body, e.g. in headers.
+		return jsonrpcReqResp.InvalidRequestError, index, ""
+	}
+	if path == "" && status == http.StatusBadRequest {
+		// Huma could not unmarshal the body.
+		return jsonrpcReqResp.ParseError, index, ""
+	}
+
+	if c.isBatch && strings.HasPrefix(path, "[") {
+		end := strings.IndexByte(path, ']')
+		if i, err := strconv.Atoi(path[1:max(end, 1)]); end > 0 && err == nil {
+			index = i
+			path = path[end+1:]
+		}
+	}
+	field := strings.TrimPrefix(path, ".")
+	if end := strings.IndexAny(field, ".["); end >= 0 {
+		field = field[:end]
+	}
+
+	switch field {
+	case "params":
+		return jsonrpcReqResp.InvalidParamsError, index, ""
+	case "", "method":
+		// The item matched no request schema, which happens for unknown methods.
+		if name, ok := c.unknownMethod(index); ok {
+			return jsonrpcReqResp.MethodNotFoundError, index, name
+		}
+	}
+	return jsonrpcReqResp.InvalidRequestError, index, ""
+}
+
+// singleIndex returns the index of the item of a request that is not a batch, -1 for batches.
+func (c *errorClassifier) singleIndex() int {
+	if !c.isBatch && len(c.items) == 1 {
+		return 0
+	}
+	return -1
+}
+
+// unknownMethod returns the method of the item at index if it is a valid request for a method that
+// does not exist.
+func (c *errorClassifier) unknownMethod(index int) (string, bool) {
+	fields := c.item(index)
+	var version, methodName string
+	if json.Unmarshal(fields["jsonrpc"], &version) != nil || version != jsonrpcReqResp.JSONRPCVersion ||
+		json.Unmarshal(fields["method"], &methodName) != nil {
+		return "", false
+	}
+	return methodName, !c.methodExists(methodName)
+}
+
+// requestID returns the ID of the item at index, nil if there is none.
+func (c *errorClassifier) requestID(index int) *jsonrpcReqResp.RequestID {
+	raw, ok := c.item(index)["id"]
+	if !ok {
+		return nil
+	}
+	var id jsonrpcReqResp.RequestID
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return nil
+	}
+	return &id
+}
+
+// item returns the fields of the item at index, nil if it is unknown or not an object.
+func (c *errorClassifier) item(index int) map[string]json.RawMessage {
+	if index < 0 || index >= len(c.items) {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(c.items[index], &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// splitBatch returns the items of a request body, nil if body is not JSON.
+func splitBatch(body []byte) (items []json.RawMessage, isBatch bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, false
+		}
+		return items, true
+	}
+	if !json.Valid(body) {
+		return nil, false
+	}
+	return []json.RawMessage{body}, false
+}
+
+func rank(code jsonrpcReqResp.JSONRPCErrorCode) int {
+	return slices.Index(errorCodeRank, code)
+}
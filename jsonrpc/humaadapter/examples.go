@@ -0,0 +1,293 @@
+package humaadapter
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// BatchExampleName is the name of the batch example of the JSONRPC operations.
+const BatchExampleName = "batch"
+
+// batchExampleSize is the number of method calls in the batch example.
+const batchExampleSize = 2
+
+// exampleRequestID is the ID of the example requests.
+var exampleRequestID = jsonrpcReqResp.RequestID{Value: 1}
+
+// notificationResponse is the body of the responses to requests holding only notifications.
+var notificationResponse = json.RawMessage("null")
+
+// ExampleProvider is implemented by param and result types providing their own examples, used instead
+// of the example built from the zero value of the type.
+//
+// Example:
+//
+//	func (AddParams) Examples() []any {
+//	    return []any{AddParams{A: 1, B: 2}, AddParams{A: -1, B: 1}}
+//	}
+type ExampleProvider interface {
+	Examples() []any
+}
+
+var exampleProviderType = reflect.TypeOf((*ExampleProvider)(nil)).Elem()
+
+// GetExamples returns the example values of t. They are the examples of an ExampleProvider, or else
+// the zero value of t, with the fields having an `example` tag set from it.
+func GetExamples(t reflect.Type) []any {
+	if t == nil {
+		return []any{nil}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(exampleProviderType) {
+		if examples := reflect.New(t).Interface().(ExampleProvider).Examples(); len(examples) > 0 {
+			return examples
+		}
+	}
+	return []any{exampleValue(t, map[reflect.Type]bool{}).Interface()}
+}
+
+// exampleValue builds the example value of t. Types being built are in visiting, so that recursive
+// types are left empty.
+func exampleValue(t reflect.Type, visiting map[reflect.Type]bool) reflect.Value {
+	v := reflect.New(t).Elem()
+	if visiting[t] {
+		return v
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if !visiting[t.Elem()] {
+			elem := reflect.New(t.Elem())
+			elem.Elem().Set(exampleValue(t.Elem(), visiting))
+			v.Set(elem)
+		}
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if tag, ok := f.Tag.Lookup("example"); ok && setExampleTag(v.Field(i), tag) {
+				continue
+			}
+			v.Field(i).Set(exampleValue(f.Type, visiting))
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(t, 0, 1))
+		if elem := t.Elem(); elem.Kind() == reflect.Struct ||
+			(elem.Kind() == reflect.Pointer && elem.Elem().Kind() == reflect.Struct) {
+			v.Set(reflect.Append(v, exampleValue(elem, visiting)))
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+	}
+	return v
+}
+
+// setExampleTag sets v from the value of its `example` tag, with the huma conventions: strings are
+// used as is, lists of strings can be comma separated, and other values are JSON.
+func setExampleTag(v reflect.Value, tag string) bool {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(tag)
+		return true
+	case json.Unmarshal([]byte(tag), v.Addr().Interface()) == nil:
+		return true
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		items := strings.Split(tag, ",")
+		v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		for i, item := range items {
+			v.Index(i).SetString(item)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// methodExample is an example call of a method or notification.
+type methodExample struct {
+	name           string
+	summary        string
+	request        any
+	response       any
+	isNotification bool
+}
+
+// getMethodExamples returns the example calls of the methods and notifications, by name.
+// Methods with several examples get a numbered example per value, after the first.
+func getMethodExamples(
+	methodMap map[string]jsonrpcReqResp.IMethodHandler,
+	notificationMap map[string]jsonrpcReqResp.INotificationHandler,
+) []methodExample {
+	var examples []methodExample
+	for _, methodName := range sortedKeys(methodMap) {
+		paramsType, resultType := methodMap[methodName].GetTypes()
+		results := GetExamples(resultType)
+		for i, params := range GetExamples(paramsType) {
+			examples = append(examples, methodExample{
+				name:    exampleName(methodName, i),
+				summary: methodName,
+				request: jsonrpcReqResp.Request[any]{
+					JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+					ID:      exampleRequestID,
+					Method:  methodName,
+					Params:  params,
+				},
+				response: jsonrpcReqResp.Response[any]{
+					JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+					ID:      &exampleRequestID,
+					Result:  results[min(i, len(results)-1)],
+				},
+			})
+		}
+	}
+	for _, methodName := range sortedKeys(notificationMap) {
+		for i, params := range GetExamples(notificationMap[methodName].GetTypes()) {
+			examples = append(examples, methodExample{
+				name:    exampleName(methodName, i),
+				summary: methodName + " (notification)",
+				request: jsonrpcReqResp.Notification[any]{
+					JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+					Method:  methodName,
+					Params:  params,
+				},
+				response:       notificationResponse,
+				isNotification: true,
+			})
+		}
+	}
+	return examples
+}
+
+// AddExamplesToOperation sets the examples of the JSON request and response bodies of a JSONRPC
+// operation: a request and its response per method and notification, and a batch of method calls and
+// a notification, named BatchExampleName. Notifications get a null body.
+//
+// Examples are built from the param and result types of the handlers, see GetExamples.
+func AddExamplesToOperation(
+	op *huma.Operation,
+	methodMap map[string]jsonrpcReqResp.IMethodHandler,
+	notificationMap map[string]jsonrpcReqResp.INotificationHandler,
+) {
+	addExamplesToOperation(op, methodMap, notificationMap, true)
+}
+
+// addExamplesToOperation is AddExamplesToOperation, setting the examples of the request body only
+// unless withResponses.
+func addExamplesToOperation(
+	op *huma.Operation,
+	methodMap map[string]jsonrpcReqResp.IMethodHandler,
+	notificationMap map[string]jsonrpcReqResp.INotificationHandler,
+	withResponses bool,
+) {
+	requestExamples := map[string]*huma.Example{}
+	responseExamples := map[string]*huma.Example{}
+	var batchRequest, batchResponse []any
+	var hasNotification bool
+	for _, example := range getMethodExamples(methodMap, notificationMap) {
+		requestExamples[example.name] = &huma.Example{Summary: example.summary, Value: example.request}
+		responseExamples[example.name] = &huma.Example{Summary: example.summary, Value: example.response}
+		if example.isNotification {
+			if !hasNotification {
+				batchRequest = append(batchRequest, example.request)
+				hasNotification = true
+			}
+			continue
+		}
+		if len(batchResponse) < batchExampleSize {
+			// Each request of a batch needs its own ID.
+			id := jsonrpcReqResp.RequestID{Value: len(batchResponse) + 1}
+			request := example.request.(jsonrpcReqResp.Request[any])
+			request.ID = id
+			response := example.response.(jsonrpcReqResp.Response[any])
+			response.ID = &id
+			batchRequest = append(batchRequest, request)
+			batchResponse = append(batchResponse, response)
+		}
+	}
+	if len(batchRequest) > 0 {
+		requestExamples[BatchExampleName] = &huma.Example{
+			Summary:     "Batch",
+			Description: "Calls are answered in a batch response, notifications get no response.",
+			Value:       batchRequest,
+		}
+	}
+	if len(batchResponse) > 0 {
+		responseExamples[BatchExampleName] = &huma.Example{Summary: "Batch", Value: batchResponse}
+	}
+
+	if op.RequestBody == nil {
+		op.RequestBody = &huma.RequestBody{}
+	}
+	op.RequestBody.Content = withJSONExamples(op.RequestBody.Content, requestExamples)
+	if !withResponses {
+		return
+	}
+	if op.Responses == nil {
+		op.Responses = map[string]*huma.Response{}
+	}
+	status := strconv.Itoa(cmp.Or(op.DefaultStatus, http.StatusOK))
+	if op.Responses[status] == nil {
+		op.Responses[status] = &huma.Response{
+			Description: "The response, or the array of responses of a batch. " +
+				"Notifications get no response, so requests holding only notifications get a null body.",
+		}
+	}
+	op.Responses[status].Content = withJSONExamples(op.Responses[status].Content, responseExamples)
+}
+
+// withJSONExamples sets the examples of the JSON media type of content, adding it if needed.
+// Existing media types are kept, so that huma sets their schema and later updates of their examples
+// are served.
+func withJSONExamples(
+	content map[string]*huma.MediaType,
+	examples map[string]*huma.Example,
+) map[string]*huma.MediaType {
+	if content == nil {
+		content = map[string]*huma.MediaType{}
+	}
+	if content["application/json"] == nil {
+		content["application/json"] = &huma.MediaType{}
+	}
+	content["application/json"].Examples = examples
+	return content
+}
+
+// addRegistryExamplesToOperation adds the examples of the handlers in registry to op, the JSONRPC
+// operation of api, and keeps them updated as handlers are registered or unregistered at runtime.
+// The updated examples are served by the OpenAPI document routes of APIs created with NewAPI.
+func addRegistryExamplesToOperation(api huma.API, op *huma.Operation, registry HandlerSource, withResponses bool) {
+	followRegistry(api, registry, func(*apiSpec) {
+		addExamplesToOperation(op, registry.MethodMap(), registry.NotificationMap(), withResponses)
+	})
+}
+
+func exampleName(methodName string, index int) string {
+	if index == 0 {
+		return methodName
+	}
+	return fmt.Sprintf("%s-%d", methodName, index+1)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,144 @@
+package humaadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+type GreetParams struct {
+	Name  string   `json:"name"            example:"Ada"`
+	Langs []string `json:"langs,omitempty" example:"en,fr"`
+	Times int      `json:"times"           example:"2"`
+	Inner struct {
+		Polite bool `json:"polite" example:"true"`
+	} `json:"inner"`
+}
+
+type DivParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (DivParams) Examples() []any {
+	return []any{DivParams{A: 6, B: 3}, DivParams{A: 1, B: 0}}
+}
+
+func TestExamples(t *testing.T) {
+	api := humatest.Wrap(t, NewAPI(huma.DefaultConfig("Test API", "1.0.0"), humatest.NewAdapter()))
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("greet", &jsonrpcReqResp.MethodHandler[GreetParams, string]{
+		Endpoint: func(ctx context.Context, params GreetParams) (string, error) {
+			return "Hello " + params.Name, nil
+		},
+	})
+	registry.RegisterMethod("div", &jsonrpcReqResp.MethodHandler[*DivParams, int]{
+		Endpoint: func(ctx context.Context, params *DivParams) (int, error) {
+			return params.A / params.B, nil
+		},
+	})
+	registry.RegisterNotification("log", &jsonrpcReqResp.NotificationHandler[map[string]any]{
+		Endpoint: func(ctx context.Context, params map[string]any) error {
+			return nil
+		},
+	})
+	RegisterRegistry(api, GetDefaultOperation(), registry)
+
+	op := api.OpenAPI().Paths["/jsonrpc"].Post
+	examples := op.RequestBody.Content["application/json"].Examples
+	for name, want := range map[string]string{
+		"greet": `{"jsonrpc":"2.0","id":1,"method":"greet",` +
+			`"params":{"name":"Ada","langs":["en","fr"],"times":2,"inner":{"polite":true}}}`,
+		"div":   `{"jsonrpc":"2.0","id":1,"method":"div","params":{"a":6,"b":3}}`,
+		"div-2": `{"jsonrpc":"2.0","id":1,"method":"div","params":{"a":1,"b":0}}`,
+		"log":   `{"jsonrpc":"2.0","method":"log","params":{}}`,
+		BatchExampleName: `[{"jsonrpc":"2.0","id":1,"method":"div","params":{"a":6,"b":3}},` +
+			`{"jsonrpc":"2.0","id":2,"method":"div","params":{"a":1,"b":0}},` +
+			`{"jsonrpc":"2.0","method":"log","params":{}}]`,
+	} {
+		if got := exampleJSON(t, examples, name); got != want {
+			t.Errorf("Expected request example %s to be %s, got %s", name, want, got)
+		}
+	}
+
+	responses := op.Responses["200"]
+	if !strings.Contains(responses.Description, "null body") {
+		t.Errorf("Expected empty body of notifications to be documented, got %q", responses.Description)
+	}
+	responseExamples := responses.Content["application/json"].Examples
+	if got := exampleJSON(t, responseExamples, "greet"); got != `{"jsonrpc":"2.0","id":1,"result":""}` {
+		t.Errorf("Unexpected response example %s", got)
+	}
+	if got := exampleJSON(t, responseExamples, "log"); got != "null" {
+		t.Errorf("Expected null response example for notifications, got %s", got)
+	}
+	if responses.Content["application/json"].Schema == nil {
+		t.Errorf("Expected the response schema to be kept")
+	}
+
+	// The examples are valid requests.
+	resp := api.Post("/jsonrpc", examples[BatchExampleName].Value)
+	if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), `"result":2`) {
+		t.Errorf("Unexpected batch response %d %s", resp.Code, resp.Body.String())
+	}
+	resp = api.Post("/jsonrpc", examples["log"].Value)
+	if resp.Code != http.StatusOK || strings.TrimSpace(resp.Body.String()) != "null" {
+		t.Errorf("Expected null body for notifications, got %d %q", resp.Code, resp.Body.String())
+	}
+
+	// The served examples follow the registry.
+	servedExamples := func() map[string]json.RawMessage {
+		t.Helper()
+		var doc struct {
+			Paths map[string]struct {
+				Post struct {
+					RequestBody struct {
+						Content map[string]struct {
+							Examples map[string]json.RawMessage `json:"examples"`
+						} `json:"content"`
+					} `json:"requestBody"`
+				} `json:"post"`
+			} `json:"paths"`
+		}
+		resp := api.Get("/openapi.json")
+		if err := json.Unmarshal(resp.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("Invalid document: %v", err)
+		}
+		return doc.Paths["/jsonrpc"].Post.RequestBody.Content["application/json"].Examples
+	}
+	if served := servedExamples(); served["div"] == nil || served["greet"] == nil {
+		t.Errorf("Expected the examples to be served, got %v", served)
+	}
+	registry.UnregisterMethod("div")
+	registry.RegisterMethod("hello", &jsonrpcReqResp.MethodHandler[GreetParams, string]{
+		Endpoint: func(ctx context.Context, params GreetParams) (string, error) {
+			return "Hello " + params.Name, nil
+		},
+	})
+	served := servedExamples()
+	if served["div"] != nil || served["div-2"] != nil {
+		t.Errorf("Expected the examples of unregistered methods to be removed")
+	}
+	if served["hello"] == nil {
+		t.Errorf("Expected the examples of registered methods to be added")
+	}
+}
+
+func exampleJSON(t *testing.T, examples map[string]*huma.Example, name string) string {
+	t.Helper()
+	example, ok := examples[name]
+	if !ok {
+		return ""
+	}
+	b, err := json.Marshal(example.Value)
+	if err != nil {
+		t.Fatalf("Invalid example %s: %v", name, err)
+	}
+	return string(b)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -15,10 +16,11 @@ type (
 	NotificationAny = jsonrpcReqResp.Notification[any]
 )
 
+// IntStringSchema is the schema of an IntString, which accepts any JSON number or string.
 func IntStringSchema() *huma.Schema {
 	return &huma.Schema{
 		OneOf: []*huma.Schema{
-			{Type: huma.TypeInteger},
+			{Type: huma.TypeNumber},
 			{Type: huma.TypeString},
 		},
 	}
@@ -130,7 +132,22 @@ func getRequestSchema(
 		}
 		if !isNotification {
 			reqSubSchema.Properties["id"] = IntStringSchema()
-			reqSubSchema.Required = append(reqSubSchema.Required, "id")
+			addRequired(reqSubSchema, "id")
+		}
+		// Params that can be bound by position are also accepted as an array.
+		if minArity, maxArity, ok := jsonrpcReqResp.PositionalArity(paramType); ok {
+			if paramsSchema := reqSubSchema.Properties["params"]; paramsSchema != nil &&
+				paramsSchema.OneOf == nil {
+				positionalSchema := &huma.Schema{
+					Type:     huma.TypeArray,
+					MinItems: intPtr(minArity),
+					MaxItems: intPtr(maxArity),
+				}
+				positionalSchema.PrecomputeMessages()
+				reqSubSchema.Properties["params"] = &huma.Schema{
+					OneOf: []*huma.Schema{paramsSchema, positionalSchema},
+				}
+			}
 		}
 	}
 	return reqSchema
@@ -158,13 +175,13 @@ func getResponseSchema(
 	if successSchema.Ref != "" {
 		reqSubSchema := api.OpenAPI().Components.Schemas.SchemaFromRef(successSchema.Ref)
 		reqSubSchema.Properties["id"] = IntStringSchema()
-		reqSubSchema.Required = append(reqSubSchema.Required, "id")
+		addRequired(reqSubSchema, "id")
 	}
 	errorSchema := getTypeSchema(api, methodName, errorResponseType, "ErrorResponse")
 	if errorSchema.Ref != "" {
 		reqSubSchema := api.OpenAPI().Components.Schemas.SchemaFromRef(errorSchema.Ref)
 		reqSubSchema.Properties["id"] = IntStringSchema()
-		reqSubSchema.Required = append(reqSubSchema.Required, "id")
+		addRequired(reqSubSchema, "id")
 	}
 
 	// Build the response schema with OneOf combining the two schemas.
@@ -231,10 +248,24 @@ func getErrorResponseType(errorType reflect.Type) reflect.Type {
 	return reflect.StructOf(fields)
 }
 
+// AddSchemasToAPI adds the request and response schemas of the handlers to the API, as the oneOf of the
+// JSONRPC request and response schemas. For APIs created with NewAPI, schemas added by a previous call
+// and no longer used are removed when the document is served.
 func AddSchemasToAPI(
 	api huma.API,
 	methodMap map[string]jsonrpcReqResp.IMethodHandler,
 	notificationMap map[string]jsonrpcReqResp.INotificationHandler,
+) {
+	updateSpec(api, func(spec *apiSpec) {
+		addSchemasToAPI(api, spec, methodMap, notificationMap)
+	})
+}
+
+func addSchemasToAPI(
+	api huma.API,
+	spec *apiSpec,
+	methodMap map[string]jsonrpcReqResp.IMethodHandler,
+	notificationMap map[string]jsonrpcReqResp.INotificationHandler,
 ) {
 	// Prepare slices to hold per-method request and response schemas.
 	reqSchemas := make([]*huma.Schema, 0, len(methodMap)+len(notificationMap))
@@ -309,9 +340,34 @@ func AddSchemasToAPI(
 				Type:     huma.TypeArray,
 				Items:    baseRespSchema,
 				MinItems: intPtr(1),
+			}, {
+				Type:        "null",
+				Description: "Requests holding only notifications get no response.",
 			},
 		},
 	}
+
+	// The base schemas are kept until the operation referring to them is registered.
+	for _, t := range []reflect.Type{reqType, respType, elementType, batchItemType, batchItemResponseType} {
+		spec.keep(api.OpenAPI().Components.Schemas.Schema(t, true, "").Ref)
+	}
+}
+
+// AddRegistrySchemasToAPI adds the schemas of the handlers in registry, e.g. a jsonrpcReqResp.Registry,
+// to the API and keeps them updated as handlers are registered or unregistered at runtime.
+// The updated schemas are served by the OpenAPI document routes of APIs created with NewAPI, see NewAPI.
+// The returned function stops following the registry.
+func AddRegistrySchemasToAPI(api huma.API, registry HandlerSource) (unsubscribe func()) {
+	return followRegistry(api, registry, func(spec *apiSpec) {
+		addSchemasToAPI(api, spec, registry.MethodMap(), registry.NotificationMap())
+	})
+}
+
+// addRequired marks a property as required once, so that schemas can be regenerated.
+func addRequired(schema *huma.Schema, name string) {
+	if !slices.Contains(schema.Required, name) {
+		schema.Required = append(schema.Required, name)
+	}
 }
 
 func intPtr(i int) *int {
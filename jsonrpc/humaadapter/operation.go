@@ -0,0 +1,371 @@
+package humaadapter
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// OperationBodyParam is the name of the param holding the body of a wrapped huma operation.
+const OperationBodyParam = "body"
+
+// operationParamLocations are the struct tags of huma input fields bound to request parameters.
+var operationParamLocations = []string{"path", "query", "header", "cookie"}
+
+// unforwardedHeaders are the headers of the peer describing its JSONRPC message or connection, which
+// are not forwarded to the requests of the wrapped operations.
+var unforwardedHeaders = []string{
+	"Accept", "Accept-Encoding", "Connection", "Content-Encoding", "Content-Length", "Content-Type",
+	"Keep-Alive", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// operationParamTags are the struct tags of huma input fields kept in the params type.
+var operationParamTags = []string{
+	"doc", "example", "default", "enum", "format", "pattern", "nullable", "deprecated",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "minItems", "maxItems", "uniqueItems",
+}
+
+// OperationHandler is a method handler calling a huma operation, so that REST operations can be
+// served by the JSONRPC transports too.
+//
+// The params of the method are an object with a property per path, query, header and cookie parameter
+// of the operation, and the body of the operation in OperationBodyParam. The result is the response
+// body. Requests go through the huma adapter of the API, so the parameters are parsed and validated,
+// and the middlewares of the API and of the operation run, as for HTTP requests. The headers and the
+// remote address of the jsonrpcReqResp.PeerInfo of the call are forwarded, e.g. for authentication
+// middlewares, except for the headers describing the JSONRPC message, e.g. Content-Type. Header
+// params of the operation replace the forwarded headers.
+//
+// HTTP errors are mapped to JSONRPC errors by CodeFromHTTPStatus, with the error body as data.
+//
+// Example:
+//
+//	huma.Register(api, huma.Operation{OperationID: "get-item", Method: http.MethodGet, Path: "/items/{id}"}, getItem)
+//	handler, err := humaadapter.NewOperationHandler[GetItemInput, GetItemOutput](api, "get-item")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	registry.RegisterMethod("items/get", handler)
+type OperationHandler[I, O any] struct {
+	api huma.API
+	op  *huma.Operation
+	// paramsType and resultType are returned by GetTypes.
+	paramsType reflect.Type
+	resultType reflect.Type
+}
+
+// NewOperationHandler creates a method handler calling the operation of api with operationID,
+// which was registered with the input type I and the output type O. Hidden operations cannot be
+// found, as they are not in the OpenAPI document. An error is returned if two parameters of I, or a
+// parameter and the body, have the same name, as they cannot be told apart in params.
+func NewOperationHandler[I, O any](api huma.API, operationID string) (*OperationHandler[I, O], error) {
+	for _, pathItem := range api.OpenAPI().Paths {
+		for _, op := range []*huma.Operation{
+			pathItem.Get, pathItem.Put, pathItem.Post, pathItem.Delete,
+			pathItem.Options, pathItem.Head, pathItem.Patch, pathItem.Trace,
+		} {
+			if op == nil || op.OperationID != operationID {
+				continue
+			}
+			fields, err := operationParamFields(reflect.TypeOf((*I)(nil)).Elem())
+			if err != nil {
+				return nil, fmt.Errorf("operation %q: %w", operationID, err)
+			}
+			resultType := reflect.TypeOf((*struct{})(nil))
+			if f, ok := reflect.TypeOf((*O)(nil)).Elem().FieldByName("Body"); ok {
+				resultType = f.Type
+			}
+			return &OperationHandler[I, O]{
+				api:        api,
+				op:         op,
+				paramsType: reflect.StructOf(fields),
+				resultType: resultType,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("operation %q not found", operationID)
+}
+
+// Handle calls the operation with the params of req.
+func (h *OperationHandler[I, O]) Handle(
+	ctx context.Context,
+	req jsonrpcReqResp.Request[json.RawMessage],
+) jsonrpcReqResp.Response[json.RawMessage] {
+	httpReq, err := h.newRequest(ctx, req.Params)
+	if err != nil {
+		return jsonrpcReqResp.Response[json.RawMessage]{
+			JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+			ID:      &req.ID,
+			Error: &jsonrpcReqResp.JSONRPCError{
+				Code:    jsonrpcReqResp.InvalidParamsError,
+				Message: jsonrpcReqResp.GetDefaultErrorMessage(jsonrpcReqResp.InvalidParamsError) + ": " + err.Error(),
+			},
+		}
+	}
+
+	w := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+	h.api.Adapter().ServeHTTP(w, httpReq)
+
+	body := bytes.TrimSpace(w.body.Bytes())
+	if w.status >= 200 && w.status < 300 {
+		result := json.RawMessage(body)
+		switch {
+		case len(body) == 0:
+			result = json.RawMessage("null")
+		case !json.Valid(body):
+			// Bodies that are not JSON are returned as a string.
+			result, _ = json.Marshal(string(body))
+		}
+		return jsonrpcReqResp.Response[json.RawMessage]{
+			JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+			ID:      &req.ID,
+			Result:  result,
+		}
+	}
+	return jsonrpcReqResp.Response[json.RawMessage]{
+		JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+		ID:      &req.ID,
+		Error:   httpError(w.status, body),
+	}
+}
+
+// GetTypes returns the type of the params, built from the parameters and the body of I, and the type
+// of the body of O.
+func (h *OperationHandler[I, O]) GetTypes() (iType, oType reflect.Type) {
+	return h.paramsType, h.resultType
+}
+
+// newRequest builds the HTTP request of the operation for params.
+func (h *OperationHandler[I, O]) newRequest(ctx context.Context, rawParams json.RawMessage) (*http.Request, error) {
+	var params map[string]json.RawMessage
+	if trimmed := bytes.TrimSpace(rawParams); len(trimmed) > 0 && string(trimmed) != "null" {
+		if err := json.Unmarshal(trimmed, &params); err != nil {
+			return nil, fmt.Errorf("params must be an object: %w", err)
+		}
+	}
+
+	path := h.op.Path
+	query := url.Values{}
+	header := http.Header{}
+	for _, p := range h.op.Parameters {
+		raw, ok := params[p.Name]
+		if !ok || string(raw) == "null" {
+			if p.In == "path" {
+				return nil, fmt.Errorf("missing required path param %s", p.Name)
+			}
+			continue
+		}
+		values, err := paramValues(raw)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %w", p.Name, err)
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(strings.Join(values, ",")))
+		case "query":
+			if p.Explode != nil && *p.Explode {
+				query[p.Name] = values
+			} else {
+				query.Set(p.Name, strings.Join(values, ","))
+			}
+		case "header":
+			header.Set(p.Name, strings.Join(values, ","))
+		case "cookie":
+			header.Add("Cookie", (&http.Cookie{Name: p.Name, Value: strings.Join(values, ",")}).String())
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var body []byte
+	if raw, ok := params[OperationBodyParam]; ok && string(raw) != "null" {
+		body = raw
+		header.Set("Content-Type", "application/json")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, h.op.Method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if info, ok := jsonrpcReqResp.GetPeerInfo(ctx); ok {
+		httpReq.RemoteAddr = info.RemoteAddr
+		for name, values := range info.Header {
+			httpReq.Header[name] = slices.Clone(values)
+		}
+		for _, name := range unforwardedHeaders {
+			httpReq.Header.Del(name)
+		}
+	}
+	for name, values := range header {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	return httpReq, nil
+}
+
+// paramValues returns the text of a parameter value, one per item for arrays.
+func paramValues(raw json.RawMessage) ([]string, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		items = []json.RawMessage{raw}
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		var value any
+		if err := json.Unmarshal(item, &value); err != nil {
+			return nil, err
+		}
+		switch v := value.(type) {
+		case string:
+			values = append(values, v)
+		case map[string]any, []any:
+			return nil, fmt.Errorf("unsupported value %s", item)
+		default:
+			values = append(values, string(bytes.TrimSpace(item)))
+		}
+	}
+	return values, nil
+}
+
+// operationParamFields returns the fields of the params type of a huma input type. Like huma, it
+// skips unexported fields and flattens the exported embedded structs.
+func operationParamFields(inputType reflect.Type) ([]reflect.StructField, error) {
+	var fields []reflect.StructField
+	// The Go and the JSON names of the fields must be unique.
+	fieldNames, paramNames := map[string]bool{}, map[string]bool{}
+	add := func(f reflect.StructField) error {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if fieldNames[f.Name] {
+			return fmt.Errorf("duplicate field %s", f.Name)
+		}
+		if paramNames[name] {
+			return fmt.Errorf("duplicate param %s", name)
+		}
+		fieldNames[f.Name], paramNames[name] = true, true
+		fields = append(fields, f)
+		return nil
+	}
+	for i := range inputType.NumField() {
+		f := inputType.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if embedded := f.Type; f.Anonymous {
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				embeddedFields, err := operationParamFields(embedded)
+				if err != nil {
+					return nil, err
+				}
+				for _, ef := range embeddedFields {
+					if err := add(ef); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+		}
+
+		name, required := "", false
+		for _, location := range operationParamLocations {
+			if value, ok := f.Tag.Lookup(location); ok {
+				name = strings.Split(value, ",")[0]
+				required = location == "path" || f.Tag.Get("required") == "true"
+				break
+			}
+		}
+		if f.Name == "Body" {
+			name = OperationBodyParam
+			required = f.Type.Kind() != reflect.Ptr && f.Type.Kind() != reflect.Interface
+		}
+		if name == "" {
+			continue
+		}
+
+		tag := fmt.Sprintf(`json:"%s,omitempty"`, name)
+		if required {
+			tag = fmt.Sprintf(`json:"%s" required:"true"`, name)
+		}
+		for _, key := range operationParamTags {
+			if value, ok := f.Tag.Lookup(key); ok {
+				tag += fmt.Sprintf(` %s:%q`, key, value)
+			}
+		}
+		if err := add(reflect.StructField{Name: f.Name, Type: f.Type, Tag: reflect.StructTag(tag)}); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// CodeFromHTTPStatus returns the JSONRPC error code for an HTTP error status of a huma operation.
+// Parameters that cannot be parsed or are invalid are invalid params, other client errors are
+// invalid requests, and server errors are internal errors.
+func CodeFromHTTPStatus(status int) jsonrpcReqResp.JSONRPCErrorCode {
+	switch {
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return jsonrpcReqResp.InvalidParamsError
+	case status >= 400 && status < 500:
+		return jsonrpcReqResp.InvalidRequestError
+	default:
+		return jsonrpcReqResp.InternalError
+	}
+}
+
+// httpError returns the JSONRPC error for an HTTP error response. The message is the detail of a
+// huma error model, if any, and the data is the error body.
+func httpError(status int, body []byte) *jsonrpcReqResp.JSONRPCError {
+	code := CodeFromHTTPStatus(status)
+	message := jsonrpcReqResp.GetDefaultErrorMessage(code)
+	var model struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(body, &model) == nil {
+		if detail := cmp.Or(model.Detail, model.Title); detail != "" {
+			message += ": " + detail
+		}
+	}
+	jsonRPCError := &jsonrpcReqResp.JSONRPCError{Code: code, Message: message}
+	if len(body) > 0 && json.Valid(body) {
+		jsonRPCError.Data = json.RawMessage(body)
+	}
+	return jsonRPCError
+}
+
+// responseRecorder is the http.ResponseWriter of the requests of an OperationHandler.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *responseRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
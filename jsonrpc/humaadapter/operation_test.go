@@ -0,0 +1,224 @@
+package humaadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+type Item struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags,omitempty"`
+	Count int      `json:"count"`
+}
+
+type UpdateItemInput struct {
+	ID    string   `path:"id"`
+	Tags  []string `query:"tags"`
+	Count int      `query:"count" minimum:"0"`
+	Body  struct {
+		Name string `json:"name" minLength:"1"`
+	}
+}
+
+type UpdateItemOutput struct {
+	Body Item
+}
+
+func TestOperationHandler(t *testing.T) {
+	_, api := humatest.New(t)
+	requireAuth := func(ctx huma.Context, next func(huma.Context)) {
+		if ctx.Header("Authorization") != "Bearer token" {
+			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(ctx)
+	}
+	huma.Register(api, huma.Operation{
+		OperationID: "update-item",
+		Method:      http.MethodPut,
+		Path:        "/items/{id}",
+		Middlewares: huma.Middlewares{requireAuth},
+	}, func(ctx context.Context, input *UpdateItemInput) (*UpdateItemOutput, error) {
+		if input.ID == "missing" {
+			return nil, huma.Error404NotFound("item not found")
+		}
+		return &UpdateItemOutput{Body: Item{
+			ID:    input.ID,
+			Name:  input.Body.Name,
+			Tags:  input.Tags,
+			Count: input.Count,
+		}}, nil
+	})
+
+	if _, err := NewOperationHandler[UpdateItemInput, UpdateItemOutput](api, "unknown"); err == nil {
+		t.Errorf("Expected error for unknown operation")
+	}
+	handler, err := NewOperationHandler[UpdateItemInput, UpdateItemOutput](api, "update-item")
+	if err != nil {
+		t.Fatalf("NewOperationHandler returned error: %v", err)
+	}
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("items/update", handler)
+	peer := jsonrpcReqResp.NewPeer(jsonrpcReqResp.NewBatchRequestHandler(jsonrpcReqResp.WithRegistry(registry)), nil)
+	// The headers of the peer are forwarded, except those of the JSONRPC message.
+	ctx := jsonrpcReqResp.ContextWithPeerInfo(t.Context(), jsonrpcReqResp.PeerInfo{
+		Header: http.Header{"Authorization": {"Bearer token"}, "Content-Type": {"text/plain"}},
+	})
+
+	for _, tc := range []struct {
+		name       string
+		params     string
+		wantResult *Item
+		wantCode   jsonrpcReqResp.JSONRPCErrorCode
+	}{
+		{
+			name:       "Path, query and body",
+			params:     `{"id": "a/b", "tags": ["x", "y"], "count": 2, "body": {"name": "first"}}`,
+			wantResult: &Item{ID: "a/b", Name: "first", Tags: []string{"x", "y"}, Count: 2},
+		},
+		{
+			name:     "Invalid body",
+			params:   `{"id": "a", "body": {"name": ""}}`,
+			wantCode: jsonrpcReqResp.InvalidParamsError,
+		},
+		{
+			name:     "Invalid query",
+			params:   `{"id": "a", "count": "many", "body": {"name": "n"}}`,
+			wantCode: jsonrpcReqResp.InvalidParamsError,
+		},
+		{
+			name:     "Missing path param",
+			params:   `{"body": {"name": "n"}}`,
+			wantCode: jsonrpcReqResp.InvalidParamsError,
+		},
+		{
+			name:     "Params not an object",
+			params:   `["a"]`,
+			wantCode: jsonrpcReqResp.InvalidParamsError,
+		},
+		{
+			name:     "Not found",
+			params:   `{"id": "missing", "body": {"name": "n"}}`,
+			wantCode: jsonrpcReqResp.InvalidRequestError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := `{"jsonrpc": "2.0", "id": 1, "method": "items/update", "params": ` + tc.params + `}`
+			reply, err := peer.HandleMessage(ctx, []byte(msg))
+			if err != nil {
+				t.Fatalf("HandleMessage returned error: %v", err)
+			}
+			var resp jsonrpcReqResp.Response[*Item]
+			if err := json.Unmarshal(reply, &resp); err != nil {
+				t.Fatalf("Invalid response %s: %v", reply, err)
+			}
+			if tc.wantResult != nil {
+				if resp.Error != nil || resp.Result == nil || resp.Result.ID != tc.wantResult.ID ||
+					resp.Result.Name != tc.wantResult.Name || resp.Result.Count != tc.wantResult.Count ||
+					strings.Join(resp.Result.Tags, ",") != strings.Join(tc.wantResult.Tags, ",") {
+					t.Errorf("Expected result %+v, got %s", tc.wantResult, reply)
+				}
+				return
+			}
+			if resp.Error == nil || resp.Error.Code != tc.wantCode {
+				t.Errorf("Expected error code %d, got %s", tc.wantCode, reply)
+			}
+		})
+	}
+
+	reply, err := peer.HandleMessage(t.Context(), []byte(
+		`{"jsonrpc": "2.0", "id": 1, "method": "items/update", "params": {"id": "a", "body": {"name": "n"}}}`,
+	))
+	if err != nil || !strings.Contains(string(reply), `"code":-32600`) {
+		t.Errorf("Expected the operation to reject calls without authorization, got %s, %v", reply, err)
+	}
+
+	paramsType, resultType := handler.GetTypes()
+	for name, wantTag := range map[string]string{
+		"ID":    `json:"id" required:"true"`,
+		"Count": `json:"count,omitempty" minimum:"0"`,
+		"Body":  `json:"body" required:"true"`,
+	} {
+		f, ok := paramsType.FieldByName(name)
+		if !ok || string(f.Tag) != wantTag {
+			t.Errorf("Expected field %s with tag %s, got %+v", name, wantTag, f)
+		}
+	}
+	if resultType.Name() != "Item" {
+		t.Errorf("Expected the body type as result type, got %v", resultType)
+	}
+
+	// The method is also served by the JSONRPC operation of the same API.
+	RegisterRegistry(api, GetDefaultOperation(), registry)
+	resp := api.Post("/jsonrpc", "Authorization: Bearer token", strings.NewReader(
+		`{"jsonrpc": "2.0", "id": 2, "method": "items/update", "params": {"id": "c", "body": {"name": "n"}}}`,
+	))
+	if !strings.Contains(resp.Body.String(), `"result":{"id":"c"`) {
+		t.Errorf("Unexpected JSONRPC response %s", resp.Body.String())
+	}
+}
+
+type PagingParams struct {
+	Limit int `query:"limit"`
+}
+
+type hiddenParams struct {
+	Secret string `query:"secret"`
+}
+
+type ListItemsInput struct {
+	PagingParams
+	hiddenParams
+	Filter string `query:"filter"`
+}
+
+type DuplicateParamInput struct {
+	PagingParams
+	Max int `query:"limit"`
+}
+
+type DuplicateFieldInput struct {
+	PagingParams
+	Limit int `header:"X-Limit"`
+}
+
+func TestOperationParamFields(t *testing.T) {
+	fields, err := operationParamFields(reflect.TypeFor[ListItemsInput]())
+	if err != nil {
+		t.Fatalf("operationParamFields returned error: %v", err)
+	}
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	if !slices.Equal(names, []string{"Limit", "Filter"}) {
+		t.Errorf("Expected the exported embedded fields to be flattened, got %v", names)
+	}
+
+	for _, inputType := range []reflect.Type{
+		reflect.TypeFor[DuplicateParamInput](),
+		reflect.TypeFor[DuplicateFieldInput](),
+	} {
+		if _, err := operationParamFields(inputType); err == nil {
+			t.Errorf("Expected an error for the duplicate names of %s", inputType.Name())
+		}
+	}
+
+	_, api := humatest.New(t)
+	huma.Get(api, "/items", func(ctx context.Context, input *DuplicateParamInput) (*UpdateItemOutput, error) {
+		return &UpdateItemOutput{}, nil
+	})
+	if _, err := NewOperationHandler[DuplicateParamInput, UpdateItemOutput](api, "get-items"); err == nil {
+		t.Errorf("Expected an error for duplicate params")
+	}
+}
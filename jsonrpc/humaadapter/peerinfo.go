@@ -0,0 +1,34 @@
+package humaadapter
+
+import (
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// DefaultTransport is the transport name of the PeerInfo set by the JSONRPC operations.
+const DefaultTransport = "http"
+
+// PeerInfoMiddleware returns an operation middleware setting the jsonrpcReqResp.PeerInfo of the
+// request in its context, with the headers and the remote address of the HTTP request.
+// Fields already set by the transport, e.g. by a middleware running before, are kept, and transport is
+// used if none is set.
+func PeerInfoMiddleware(transport string) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		info, _ := jsonrpcReqResp.GetPeerInfo(ctx.Context())
+		if info.Transport == "" {
+			info.Transport = transport
+		}
+		if info.RemoteAddr == "" {
+			info.RemoteAddr = ctx.RemoteAddr()
+		}
+		if info.Header == nil {
+			info.Header = http.Header{}
+			ctx.EachHeader(func(name, value string) {
+				info.Header.Add(name, value)
+			})
+		}
+		next(huma.WithContext(ctx, jsonrpcReqResp.ContextWithPeerInfo(ctx.Context(), info)))
+	}
+}
@@ -1,13 +1,10 @@
 package humaadapter
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
@@ -27,106 +24,61 @@ func GetDefaultOperation() huma.Operation {
 	}
 }
 
-// Response object. It implements the huma StatusError interface.
+// GetErrorHandler returns a huma.NewError replacement converting the errors of the JSONRPC operation
+// serving methodMap and notificationMap to JSONRPC error responses.
 // IF the JSONRPC handler is invoked, it should never throw an error, but should return a error response object.
 // JSONRPC requires a error case to be covered via the specifications error response object.
+// The request ID cannot be recovered without the request context, see RegisterRegistry.
 func GetErrorHandler(
 	methodMap map[string]jsonrpcReqResp.IMethodHandler,
 	notificationMap map[string]jsonrpcReqResp.INotificationHandler,
 ) func(status int, message string, errs ...error) huma.StatusError {
-	return func(gotStatus int, gotMessage string, errs ...error) huma.StatusError {
-		var foundJSONRPCError *jsonrpcReqResp.JSONRPCError
-		message := gotMessage
-		details := make([]string, 0)
-		// Add the Message and HTTP status to details and set status sent back as 200.
-		details = append(details, "Message:"+gotMessage, "HTTP Status:"+strconv.Itoa(gotStatus))
-		status := 200
-
-		code := jsonrpcReqResp.InternalError
-		if gotStatus >= 400 && gotStatus < 500 {
-			code = jsonrpcReqResp.InvalidRequestError
-			message = jsonrpcReqResp.GetDefaultErrorMessage(jsonrpcReqResp.InvalidRequestError)
-		}
-
-		for _, err := range errs {
-			var jsonRPCError jsonrpcReqResp.JSONRPCError
-			if converted, ok := err.(huma.ErrorDetailer); ok {
-				d := converted.ErrorDetail()
-				// See if this is parse error.
-				if strings.Contains(d.Message, "unmarshal") ||
-					strings.Contains(d.Message, "invalid character") ||
-					strings.Contains(d.Message, "unexpected end") {
-					code = jsonrpcReqResp.ParseError
-					message = jsonrpcReqResp.GetDefaultErrorMessage(jsonrpcReqResp.ParseError)
-				}
-			} else if errors.As(err, &jsonRPCError) {
-				// Check if the error is of type JSONRPCError.
-				foundJSONRPCError = &jsonRPCError
-			}
-			details = append(details, err.Error())
+	handler := getErrorHandler(func(methodName string) bool {
+		if _, exists := methodMap[methodName]; exists {
+			return true
 		}
+		_, exists := notificationMap[methodName]
+		return exists
+	})
+	return func(status int, message string, errs ...error) huma.StatusError {
+		return handler(nil, status, message, errs...)
+	}
+}
 
-		// If a JSONRPCError was found, update the message and append JSON-encoded details.
-		if foundJSONRPCError != nil {
-			message = foundJSONRPCError.Message
-			code = foundJSONRPCError.Code
-
-			// JSON encode the Data field of the found JSONRPCError.
-			if jsonData, err := json.Marshal(foundJSONRPCError.Data); err == nil {
-				details = append(details, string(jsonData))
-			}
-		}
+// HandlerSource is a set of handlers that can change while serving, e.g. a jsonrpcReqResp.Registry,
+// a jsonrpcReqResp.Router, or a jsonrpcReqResp.BatchRequestHandler serving both.
+type HandlerSource interface {
+	LookupMethod(name string) (jsonrpcReqResp.IMethodHandler, bool)
+	LookupNotification(name string) (jsonrpcReqResp.INotificationHandler, bool)
+	MethodMap() map[string]jsonrpcReqResp.IMethodHandler
+	NotificationMap() map[string]jsonrpcReqResp.INotificationHandler
+	Subscribe(fn func(jsonrpcReqResp.RegistryChange)) (unsubscribe func())
+}
 
-		// Check for method not found.
-		if gotMessage == "validation failed" {
-			// Assume that the method name is in one of the error messages
-			// Look for "method:<methodName>".
-			var methodName string
-			for _, errMsg := range details {
-				idx := strings.Index(errMsg, "method:")
-				if idx != -1 {
-					// Extract method name up to the next space or bracket or end of string.
-					rest := errMsg[idx+len("method:"):]
-					endIdx := strings.IndexFunc(rest, func(r rune) bool {
-						return r == ' ' || r == ']' || r == ')'
-					})
-					if endIdx == -1 {
-						methodName = rest
-					} else {
-						methodName = rest[:endIdx]
-					}
-					break
-				}
-			}
-			// Check if method exists in methodMap or notificationMap.
-			if methodName != "" {
-				if _, exists := methodMap[methodName]; !exists {
-					if _, exists := notificationMap[methodName]; !exists {
-						// Method not found.
-						// You need to define this constant.
-						code = jsonrpcReqResp.MethodNotFoundError
-						message = fmt.Sprintf("Method '%s' not found", methodName)
-					}
-				}
-			}
-		}
+// GetRegistryErrorHandler is like GetErrorHandler, but detects unknown methods using the current
+// content of the registry.
+func GetRegistryErrorHandler(
+	registry HandlerSource,
+) func(status int, message string, errs ...error) huma.StatusError {
+	handler := getRegistryErrorHandler(registry)
+	return func(status int, message string, errs ...error) huma.StatusError {
+		return handler(nil, status, message, errs...)
+	}
+}
 
-		return &ResponseStatusError{
-			status: status,
-			Response: jsonrpcReqResp.Response[any]{
-				JSONRPC: jsonrpcReqResp.JSONRPCVersion,
-				ID:      nil,
-				Error: &jsonrpcReqResp.JSONRPCError{
-					Code:    code,
-					Message: message,
-					Data:    details,
-				},
-			},
+func getRegistryErrorHandler(registry HandlerSource) ErrorHandler {
+	return getErrorHandler(func(methodName string) bool {
+		if _, exists := registry.LookupMethod(methodName); exists {
+			return true
 		}
-	}
+		_, exists := registry.LookupNotification(methodName)
+		return exists
+	})
 }
 
 // Register a new JSONRPC operation.
+// Errors of the operation are converted to JSONRPC error responses, errors of the other operations of
+// the API are left to huma.
 // The `methodMap` maps from method name to request handlers. Request clients expect a response object
 // The `notificationMap` maps from method name to notification handlers. Notification clients do not expect a response
 //
@@ -147,12 +99,87 @@ func Register(
 	responseMap map[string]jsonrpcReqResp.IResponseHandler,
 	responseHandlerMapper func(context.Context, jsonrpcReqResp.Response[json.RawMessage]) (string, error),
 ) {
-	AddSchemasToAPI(api, methodMap, notificationMap)
-	huma.NewError = GetErrorHandler(methodMap, notificationMap)
-	brh := jsonrpcReqResp.NewBatchRequestHandler(jsonrpcReqResp.WithMethodMap(methodMap),
-		jsonrpcReqResp.WithNotificationMap(notificationMap),
-		jsonrpcReqResp.WithResponseMap(responseMap, responseHandlerMapper),
+	registry := jsonrpcReqResp.NewRegistry()
+	for name, handler := range methodMap {
+		registry.RegisterMethod(name, handler)
+	}
+	for name, handler := range notificationMap {
+		registry.RegisterNotification(name, handler)
+	}
+	for name, handler := range responseMap {
+		registry.RegisterResponse(name, handler)
+	}
+	RegisterRegistry(
+		api,
+		op,
+		registry,
+		jsonrpcReqResp.WithResponseMap(nil, responseHandlerMapper),
+	)
+}
+
+// RegisterRegistry registers a new JSONRPC operation serving the handlers in registry.
+// Handlers registered or unregistered later are served and documented without re-registering the operation.
+// Handlers mounted in a router given with jsonrpcReqResp.WithRouter are documented too.
+// Additional options, e.g. a response mapper or middlewares, are passed to the underlying BatchRequestHandler.
+// Handlers can read the headers and the remote address of the request with jsonrpcReqResp.GetPeerInfo.
+// The request and response bodies are documented with examples, see AddExamplesToOperation.
+// They are decoded and encoded with the formats of api, and the results encoded by the codec of the
+// handlers, see jsonrpcReqResp.WithCodec, are re-encoded by the JSON format of api as raw JSON.
+func RegisterRegistry(
+	api huma.API,
+	op huma.Operation,
+	registry *jsonrpcReqResp.Registry,
+	opts ...jsonrpcReqResp.HandlerOption,
+) {
+	brh := jsonrpcReqResp.NewBatchRequestHandler(
+		append([]jsonrpcReqResp.HandlerOption{jsonrpcReqResp.WithRegistry(registry)}, opts...)...,
+	)
+	AddRegistrySchemasToAPI(api, brh)
+	op.Middlewares = append(op.Middlewares, BufferBodyMiddleware(op.MaxBodyBytes), PeerInfoMiddleware(DefaultTransport))
+	addRegistryExamplesToOperation(api, &op, brh, true)
+
+	RegisterWithErrorHandler(api, op, getRegistryErrorHandler(brh), brh.Handle)
+}
+
+// asyncResponse is the output of the operations registered with RegisterRegistryAsync. The body is
+// written by a callback, so that huma does not document it.
+type asyncResponse struct {
+	Body func(huma.Context)
+}
+
+// RegisterRegistryAsync is like RegisterRegistry, for operations whose middlewares send the JSONRPC
+// responses elsewhere, e.g. on an SSE stream, and answer the requests with op.DefaultStatus,
+// 202 Accepted if not set.
+// Only the requests are documented: op.Responses are kept as given, without the JSONRPC responses,
+// errors and their examples. The JSON response, or the JSONRPC error response of a request that
+// cannot be handled, is written to the huma.Context given by the middlewares to the operation.
+func RegisterRegistryAsync(
+	api huma.API,
+	op huma.Operation,
+	registry *jsonrpcReqResp.Registry,
+	opts ...jsonrpcReqResp.HandlerOption,
+) {
+	brh := jsonrpcReqResp.NewBatchRequestHandler(
+		append([]jsonrpcReqResp.HandlerOption{jsonrpcReqResp.WithRegistry(registry)}, opts...)...,
 	)
+	AddRegistrySchemasToAPI(api, brh)
+	op.Middlewares = append(op.Middlewares, BufferBodyMiddleware(op.MaxBodyBytes), PeerInfoMiddleware(DefaultTransport))
+	addRegistryExamplesToOperation(api, &op, brh, false)
 
-	huma.Register(api, op, brh.Handle)
+	op.DefaultStatus = cmp.Or(op.DefaultStatus, http.StatusAccepted)
+	status := op.DefaultStatus
+	registerWithErrorHandler(api, op, getRegistryErrorHandler(brh), func(
+		ctx context.Context,
+		req *jsonrpcReqResp.BatchRequest,
+	) (*asyncResponse, error) {
+		resp, err := brh.Handle(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &asyncResponse{Body: func(hctx huma.Context) {
+			hctx.SetHeader("Content-Type", "application/json")
+			hctx.SetStatus(status)
+			_ = api.Marshal(hctx.BodyWriter(), "application/json", resp.Body)
+		}}, nil
+	})
 }
@@ -0,0 +1,395 @@
+package humaadapter
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+type AddParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type EchoInput struct {
+	Body struct {
+		Message string `json:"message" minLength:"1"`
+	}
+}
+
+type EchoOutput struct {
+	Body struct {
+		Message string `json:"message"`
+	}
+}
+
+func registerOperation(api huma.API, path, method string) {
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod(method, &jsonrpcReqResp.MethodHandler[AddParams, int]{
+		Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+			return params.A + params.B, nil
+		},
+	})
+	op := GetDefaultOperation()
+	op.Path = path
+	op.OperationID = strings.TrimPrefix(path, "/")
+	RegisterRegistry(api, op, registry)
+}
+
+func TestOperationErrorHandlers(t *testing.T) {
+	_, api := humatest.New(t)
+	registerOperation(api, "/first", "add")
+	registerOperation(api, "/second", "sum")
+	huma.Post(api, "/echo", func(ctx context.Context, input *EchoInput) (*EchoOutput, error) {
+		out := &EchoOutput{}
+		out.Body.Message = input.Body.Message
+		return out, nil
+	})
+
+	for _, tc := range []struct {
+		path     string
+		body     string
+		wantCode jsonrpcReqResp.JSONRPCErrorCode
+	}{
+		{"/first", `{"jsonrpc": "2.0", "id": 1, "method": "sum", "params": {"a": 1, "b": 2}}`, jsonrpcReqResp.MethodNotFoundError},
+		{"/second", `{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a": 1, "b": 2}}`, jsonrpcReqResp.MethodNotFoundError},
+		{"/second", `{"jsonrpc": "2.0", "id": 1, "method": "sum", "params": {"a": 1, "b": 2}}`, 0},
+		{"/first", `{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a"`, jsonrpcReqResp.ParseError},
+	} {
+		resp := api.Post(tc.path, strings.NewReader(tc.body))
+		if resp.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d: %s", tc.path, resp.Code, resp.Body.String())
+		}
+		var out jsonrpcReqResp.Response[json.RawMessage]
+		if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil {
+			t.Fatalf("%s: invalid response %s: %v", tc.path, resp.Body.String(), err)
+		}
+		var gotCode jsonrpcReqResp.JSONRPCErrorCode
+		if out.Error != nil {
+			gotCode = out.Error.Code
+		}
+		if gotCode != tc.wantCode {
+			t.Errorf("%s %s: expected code %d, got %s", tc.path, tc.body, tc.wantCode, resp.Body.String())
+		}
+	}
+
+	// Errors of other operations are left to huma.
+	resp := api.Post("/echo", map[string]any{"message": ""})
+	if resp.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Unexpected status %d: %s", resp.Code, resp.Body.String())
+	}
+	var model huma.ErrorModel
+	if err := json.Unmarshal(resp.Body.Bytes(), &model); err != nil || model.Status != resp.Code {
+		t.Errorf("Expected a huma error model, got %s", resp.Body.String())
+	}
+
+	// The JSONRPC operations document their own error responses.
+	errResp := api.OpenAPI().Paths["/first"].Post.Responses["default"]
+	if errResp == nil || errResp.Content["application/json"].Schema.Properties["error"] == nil {
+		t.Errorf("Expected JSONRPC error response to be documented, got %+v", errResp)
+	} else if idSchema := errResp.Content["application/json"].Schema.Properties["id"]; len(idSchema.OneOf) == 0 ||
+		idSchema.OneOf[0].Type != huma.TypeNumber {
+		t.Errorf("Expected the error ID to accept any number, got %+v", idSchema)
+	}
+	echoErr := api.OpenAPI().Paths["/echo"].Post.Responses["default"]
+	if echoErr == nil || !strings.HasSuffix(echoErr.Content["application/problem+json"].Schema.Ref, "ErrorModel") {
+		t.Errorf("Expected huma error response for other operations, got %+v", echoErr)
+	}
+}
+
+func TestReplacedNewError(t *testing.T) {
+	_, api := humatest.New(t)
+	registerOperation(api, "/jsonrpc", "add")
+	huma.Post(api, "/echo", func(ctx context.Context, input *EchoInput) (*EchoOutput, error) {
+		return &EchoOutput{}, nil
+	})
+	// The app replaces huma.NewErrorWithContext after the JSONRPC operation is registered.
+	next := huma.NewErrorWithContext
+	t.Cleanup(func() { huma.NewErrorWithContext = next })
+	huma.NewErrorWithContext = func(ctx huma.Context, status int, msg string, errs ...error) huma.StatusError {
+		err := next(ctx, status, msg, errs...)
+		if model, ok := err.(*huma.ErrorModel); ok {
+			model.Title = "replaced"
+		}
+		return err
+	}
+
+	resp := api.Post("/jsonrpc", strings.NewReader(`{"jsonrpc": "2.0", "id": 3, "method": "add", "params": {"a": "x"}}`))
+	var out jsonrpcReqResp.Response[json.RawMessage]
+	if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil || resp.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %s", resp.Code, resp.Body.String())
+	}
+	if out.Error == nil || out.Error.Code != jsonrpcReqResp.InvalidParamsError || out.ID == nil || out.ID.Value != 3 {
+		t.Errorf("Expected an invalid params error for request 3, got %s", resp.Body.String())
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %s", ct)
+	}
+
+	resp = api.Post("/echo", map[string]any{"message": ""})
+	var model huma.ErrorModel
+	if err := json.Unmarshal(resp.Body.Bytes(), &model); err != nil || model.Title != "replaced" {
+		t.Errorf("Expected the replaced huma error for other operations, got %s", resp.Body.String())
+	}
+}
+
+func TestErrorIDRecovery(t *testing.T) {
+	_, api := humatest.New(t)
+	registerOperation(api, "/jsonrpc", "add")
+
+	for _, tc := range []struct {
+		name     string
+		body     string
+		wantCode jsonrpcReqResp.JSONRPCErrorCode
+		wantID   string
+	}{
+		{"Invalid JSON", `{"jsonrpc": "2.0", "id": 1, "method": "add"`, jsonrpcReqResp.ParseError, "null"},
+		{"Missing jsonrpc", `{"id": "a", "method": "add"}`, jsonrpcReqResp.InvalidRequestError, `"a"`},
+		{"Invalid version", `{"jsonrpc": "1.0", "id": 3, "method": "add"}`, jsonrpcReqResp.InvalidRequestError, "3"},
+		{"Invalid ID", `{"jsonrpc": "2.0", "id": [3], "method": "add"}`, jsonrpcReqResp.InvalidRequestError, "null"},
+		{"Invalid batch item", `[{"jsonrpc": "2.0", "id": 1, "method": "add"}, {"id": 2}]`, jsonrpcReqResp.InvalidRequestError, "null"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := api.Post("/jsonrpc", strings.NewReader(tc.body))
+			var out struct {
+				ID    json.RawMessage              `json:"id"`
+				Error *jsonrpcReqResp.JSONRPCError `json:"error"`
+			}
+			if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil || out.Error == nil {
+				t.Fatalf("Expected an error response, got %s", resp.Body.String())
+			}
+			if out.Error.Code != tc.wantCode {
+				t.Errorf("Expected code %d, got %d", tc.wantCode, out.Error.Code)
+			}
+			if id := string(out.ID); id != tc.wantID && !(tc.wantID == "null" && id == "") {
+				t.Errorf("Expected ID %s, got %s", tc.wantID, id)
+			}
+			if strings.Contains(resp.Body.String(), "HTTP Status") ||
+				strings.Contains(resp.Body.String(), "validation failed") {
+				t.Errorf("Expected no HTTP error text in %s", resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestClassifyErrorDetails(t *testing.T) {
+	methodExists := func(methodName string) bool {
+		return methodName == "add"
+	}
+	batch := []byte(`[{"jsonrpc": "2.0", "id": 1, "method": "add"}, {"jsonrpc": "2.0", "id": "b", "method": "add"}]`)
+	single := []byte(`{"jsonrpc": "2.0", "id": 7, "method": "nope"}`)
+
+	for _, tc := range []struct {
+		name      string
+		body      []byte
+		err       error
+		wantCode  jsonrpcReqResp.JSONRPCErrorCode
+		wantID    any
+		wantIndex *int
+	}{
+		{
+			name:      "Params of batch item",
+			body:      batch,
+			err:       &huma.ErrorDetail{Location: "body[1].params.a", Message: "expected integer"},
+			wantCode:  jsonrpcReqResp.InvalidParamsError,
+			wantID:    "b",
+			wantIndex: intPtr(1),
+		},
+		{
+			name:     "Unknown method",
+			body:     single,
+			err:      &huma.ErrorDetail{Location: "body.method", Message: "expected value to be one of"},
+			wantCode: jsonrpcReqResp.MethodNotFoundError,
+			wantID:   7,
+		},
+		{
+			name:     "Header",
+			body:     single,
+			err:      &huma.ErrorDetail{Location: "header.Content-Type", Message: "invalid"},
+			wantCode: jsonrpcReqResp.InvalidRequestError,
+			wantID:   7,
+		},
+		{
+			name:     "JSONRPC error",
+			body:     single,
+			err:      jsonrpcReqResp.JSONRPCError{Code: -32001, Message: "Unauthorized"},
+			wantCode: -32001,
+			wantID:   7,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := humatest.NewContext(
+				&huma.Operation{},
+				httptest.NewRequest(http.MethodPost, "/jsonrpc", nil),
+				httptest.NewRecorder(),
+			)
+			got := getErrorHandler(methodExists)(
+				huma.WithValue(ctx, ctxKeyBody, tc.body),
+				http.StatusUnprocessableEntity,
+				"validation failed",
+				tc.err,
+			)
+
+			resp := got.(*ResponseStatusError).Response
+			if resp.Error.Code != tc.wantCode {
+				t.Errorf("Expected code %d, got %+v", tc.wantCode, resp.Error)
+			}
+			if resp.ID == nil || resp.ID.Value != tc.wantID {
+				t.Errorf("Expected ID %v, got %+v", tc.wantID, resp.ID)
+			}
+			if tc.wantCode == jsonrpcReqResp.MethodNotFoundError && !strings.HasSuffix(resp.Error.Message, ": nope") {
+				t.Errorf("Expected method name in message, got %s", resp.Error.Message)
+			}
+			if tc.wantIndex != nil {
+				data, ok := resp.Error.Data.(ErrorData)
+				if !ok || data.BatchIndex == nil || *data.BatchIndex != *tc.wantIndex {
+					t.Errorf("Expected batch index %d, got %+v", *tc.wantIndex, resp.Error.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestPeerInfoMiddleware(t *testing.T) {
+	_, api := humatest.New(t)
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("whoami", &jsonrpcReqResp.MethodHandler[*struct{}, jsonrpcReqResp.PeerInfo]{
+		Endpoint: func(ctx context.Context, _ *struct{}) (jsonrpcReqResp.PeerInfo, error) {
+			info, _ := jsonrpcReqResp.GetPeerInfo(ctx)
+			return info, nil
+		},
+	})
+	op := GetDefaultOperation()
+	// A transport middleware running before sets its own fields.
+	op.Middlewares = huma.Middlewares{func(ctx huma.Context, next func(huma.Context)) {
+		info := jsonrpcReqResp.PeerInfo{Transport: "custom", SessionID: ctx.Query("session")}
+		next(huma.WithContext(ctx, jsonrpcReqResp.ContextWithPeerInfo(ctx.Context(), info)))
+	}}
+	RegisterRegistry(api, op, registry)
+
+	resp := api.Post("/jsonrpc?session=s1", "Authorization: Bearer token",
+		strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "whoami"}`))
+	var out jsonrpcReqResp.Response[jsonrpcReqResp.PeerInfo]
+	if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil || out.Error != nil {
+		t.Fatalf("Unexpected response %s", resp.Body.String())
+	}
+	info := out.Result
+	if info.Transport != "custom" || info.SessionID != "s1" || info.RemoteAddr == "" ||
+		info.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Unexpected peer info %+v", info)
+	}
+}
+
+func TestRegisterRouter(t *testing.T) {
+	_, api := humatest.New(t)
+	tools := jsonrpcReqResp.NewRegistry()
+	tools.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[AddParams, int]{
+		Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+			return params.A + params.B, nil
+		},
+	})
+	router := jsonrpcReqResp.NewRouter()
+	if err := router.Mount("tools/", tools); err != nil {
+		t.Fatalf("Mount returned error: %v", err)
+	}
+	op := GetDefaultOperation()
+	RegisterRegistry(api, op, jsonrpcReqResp.NewRegistry(), jsonrpcReqResp.WithRouter(router))
+
+	post := func(body string) jsonrpcReqResp.Response[json.RawMessage] {
+		t.Helper()
+		var out jsonrpcReqResp.Response[json.RawMessage]
+		resp := api.Post("/jsonrpc", strings.NewReader(body))
+		if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil {
+			t.Fatalf("Invalid response %s: %v", resp.Body.String(), err)
+		}
+		return out
+	}
+	if out := post(`{"jsonrpc": "2.0", "id": 1, "method": "tools/add", "params": {"a": 1, "b": 2}}`); string(out.Result) != "3" {
+		t.Errorf("Expected result 3, got %+v", out)
+	}
+	// The body is validated against the schemas of the mounted methods.
+	out := post(`{"jsonrpc": "2.0", "id": 1, "method": "tools/add", "params": {"a": "one", "b": 2}}`)
+	if out.Error == nil || out.Error.Code != jsonrpcReqResp.InvalidParamsError {
+		t.Errorf("Expected invalid params, got %+v", out)
+	}
+
+	schemas := api.OpenAPI().Components.Schemas.Map()
+	if schemas["Tools/addRequest"] == nil {
+		t.Errorf("Expected the request schema of the mounted method, got %v", slices.Sorted(maps.Keys(schemas)))
+	}
+	examples := api.OpenAPI().Paths["/jsonrpc"].Post.RequestBody.Content["application/json"].Examples
+	if examples["tools/add"] == nil {
+		t.Errorf("Expected an example of the mounted method, got %v", slices.Sorted(maps.Keys(examples)))
+	}
+
+	// Mounting updates the documentation.
+	admin := jsonrpcReqResp.NewRegistry()
+	admin.RegisterNotification("log", &jsonrpcReqResp.NotificationHandler[EchoInput]{
+		Endpoint: func(ctx context.Context, params EchoInput) error { return nil },
+	})
+	if err := router.Mount("admin/", admin); err != nil {
+		t.Fatalf("Mount returned error: %v", err)
+	}
+	examples = api.OpenAPI().Paths["/jsonrpc"].Post.RequestBody.Content["application/json"].Examples
+	if examples["admin/log"] == nil {
+		t.Errorf("Expected an example of the newly mounted notification, got %v", slices.Sorted(maps.Keys(examples)))
+	}
+	router.Unmount("tools/")
+	examples = api.OpenAPI().Paths["/jsonrpc"].Post.RequestBody.Content["application/json"].Examples
+	if examples["tools/add"] != nil {
+		t.Errorf("Expected no example of the unmounted method")
+	}
+}
+
+func TestRegisterRegistryAsync(t *testing.T) {
+	_, api := humatest.New(t)
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[AddParams, int]{
+		Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+			return params.A + params.B, nil
+		},
+	})
+	op := GetDefaultOperation()
+	op.DefaultStatus = 0
+	op.Responses = map[string]*huma.Response{
+		"202": {Description: "Accepted"},
+		"404": {Description: "Not found"},
+	}
+	RegisterRegistryAsync(api, op, registry)
+
+	// The middlewares of the operation get the JSONRPC responses.
+	for body, want := range map[string]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a": 1, "b": 2}}`:     `"result":3`,
+		`{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a": "one", "b": 2}}`: `"code":-32602`,
+	} {
+		resp := api.Post("/jsonrpc", strings.NewReader(body))
+		if !strings.Contains(resp.Body.String(), want) {
+			t.Errorf("Expected %s in the response, got %s", want, resp.Body.String())
+		}
+	}
+	resp := api.Post("/jsonrpc", strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a": 1, "b": 2}}`))
+	if resp.Code != http.StatusAccepted {
+		t.Errorf("Expected status %d, got %d", http.StatusAccepted, resp.Code)
+	}
+
+	// Only the requests are documented.
+	documented := api.OpenAPI().Paths["/jsonrpc"].Post
+	if keys := slices.Sorted(maps.Keys(documented.Responses)); !slices.Equal(keys, []string{"202", "404"}) {
+		t.Errorf("Expected only the given responses, got %v", keys)
+	}
+	if content := documented.Responses["202"].Content; len(content) > 0 {
+		t.Errorf("Expected no 202 body, got %v", slices.Sorted(maps.Keys(content)))
+	}
+	if examples := documented.RequestBody.Content["application/json"].Examples; examples["add"] == nil {
+		t.Errorf("Expected a request example of add, got %v", slices.Sorted(maps.Keys(examples)))
+	}
+}
@@ -0,0 +1,317 @@
+package humaadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/danielgtaylor/huma/v2"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// DefaultRESTPrefix is the conventional path prefix of the REST operations.
+const DefaultRESTPrefix = "/rpc"
+
+// restRequestIDs numbers the requests dispatched for REST calls.
+var restRequestIDs atomic.Int64
+
+// RESTError is the error body of the REST operations: the JSONRPC error object, sent with the HTTP
+// status mapped from its code by HTTPStatusFromCode.
+type RESTError struct {
+	jsonrpcReqResp.JSONRPCError
+	status int
+}
+
+func (e *RESTError) GetStatus() int {
+	return e.status
+}
+
+// NewRESTError creates the error of a REST operation for a JSONRPC error.
+func NewRESTError(jsonRPCError jsonrpcReqResp.JSONRPCError) *RESTError {
+	return &RESTError{JSONRPCError: jsonRPCError, status: HTTPStatusFromCode(jsonRPCError.Code)}
+}
+
+// HTTPStatusFromCode returns the HTTP status of the REST operations for a JSONRPC error code.
+// Errors of the request are client errors, all other codes are server errors.
+func HTTPStatusFromCode(code jsonrpcReqResp.JSONRPCErrorCode) int {
+	switch code {
+	case jsonrpcReqResp.ParseError, jsonrpcReqResp.InvalidRequestError:
+		return http.StatusBadRequest
+	case jsonrpcReqResp.MethodNotFoundError:
+		return http.StatusNotFound
+	case jsonrpcReqResp.InvalidParamsError:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetDefaultRESTOperation gets the conventional values for the REST operation of a method.
+func GetDefaultRESTOperation(prefix, methodName string) huma.Operation {
+	return huma.Operation{
+		Method:        http.MethodPost,
+		Path:          strings.TrimSuffix(prefix, "/") + "/" + methodName,
+		DefaultStatus: 200,
+
+		Tags:        []string{"JSONRPC REST"},
+		Summary:     methodName,
+		Description: "Call the JSONRPC method " + methodName,
+		OperationID: "rpc-" + strings.NewReplacer("/", "-", ".", "-").Replace(methodName),
+	}
+}
+
+type restInput struct {
+	Body *json.RawMessage
+}
+
+type restOutput struct {
+	Body json.RawMessage
+}
+
+// RegisterREST registers a POST operation per method of methodMap, at prefix followed by the method
+// name. Each operation takes the params of the method as body and returns its result, and JSONRPC
+// errors are sent as a RESTError. It is an optional addition to the JSONRPC operation.
+//
+// Calls are dispatched as JSONRPC requests to the same handler instances, so options, e.g.
+// middlewares, behave the same as for the JSONRPC operation.
+func RegisterREST(
+	api huma.API,
+	prefix string,
+	methodMap map[string]jsonrpcReqResp.IMethodHandler,
+	opts ...jsonrpcReqResp.HandlerOption,
+) {
+	registry := jsonrpcReqResp.NewRegistry()
+	for name, handler := range methodMap {
+		registry.RegisterMethod(name, handler)
+	}
+	RegisterRegistryREST(api, prefix, registry, opts...)
+}
+
+// RegisterRegistryREST is like RegisterREST for the methods of registry, and of the routers mounted
+// with jsonrpcReqResp.WithRouter. Methods registered later get an operation too, and re-registered
+// methods are documented and validated with the types of their new handler. Routes cannot be removed
+// from the router of the API, so the operation of an unregistered method is only removed from the
+// OpenAPI document, and calls to it fail with http.StatusNotFound.
+// The returned function stops following the registry.
+func RegisterRegistryREST(
+	api huma.API,
+	prefix string,
+	registry *jsonrpcReqResp.Registry,
+	opts ...jsonrpcReqResp.HandlerOption,
+) (unsubscribe func()) {
+	brh := jsonrpcReqResp.NewBatchRequestHandler(
+		append([]jsonrpcReqResp.HandlerOption{jsonrpcReqResp.WithRegistry(registry)}, opts...)...,
+	)
+	rest := &restOperations{api: api, prefix: prefix, brh: brh, ops: map[string]*huma.Operation{}}
+	unsubscribe = brh.Subscribe(func(change jsonrpcReqResp.RegistryChange) {
+		if change.Kind == jsonrpcReqResp.HandlerKindMethod {
+			rest.update(change.Name)
+		}
+	})
+	// Register in a stable order, so that the OpenAPI document is stable.
+	for _, name := range slices.Sorted(maps.Keys(brh.MethodMap())) {
+		rest.update(name)
+	}
+	return unsubscribe
+}
+
+// restOperations are the REST operations of the methods of a handler.
+type restOperations struct {
+	api    huma.API
+	prefix string
+	brh    *jsonrpcReqResp.BatchRequestHandler
+
+	mu sync.Mutex
+	// ops holds the registered operations by method name, including those of unregistered methods.
+	ops map[string]*huma.Operation
+}
+
+// update registers, documents or hides the operation of a method, as per its current handler.
+func (r *restOperations) update(methodName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	handler, exists := r.brh.LookupMethod(methodName)
+	op, registered := r.ops[methodName]
+	if !exists && !registered {
+		return
+	}
+	updateSpec(r.api, func(*apiSpec) {
+		oapi := r.api.OpenAPI()
+		switch {
+		case !registered:
+			r.ops[methodName] = registerRESTMethod(
+				r.api, GetDefaultRESTOperation(r.prefix, methodName), methodName, handler, r.brh,
+			)
+		case exists:
+			documentRESTMethod(r.api, op, methodName, handler)
+			setPathOperation(oapi, op.Path, op)
+		default:
+			setPathOperation(oapi, op.Path, nil)
+		}
+	})
+}
+
+// setPathOperation sets the POST operation of path in the OpenAPI document, removing the path if op is
+// nil and it has no other operation.
+func setPathOperation(oapi *huma.OpenAPI, path string, op *huma.Operation) {
+	item := oapi.Paths[path]
+	if item == nil {
+		if op == nil {
+			return
+		}
+		item = &huma.PathItem{}
+		oapi.Paths[path] = item
+	}
+	item.Post = op
+	if item.Get == nil && item.Put == nil && item.Post == nil && item.Delete == nil &&
+		item.Options == nil && item.Head == nil && item.Patch == nil && item.Trace == nil {
+		delete(oapi.Paths, path)
+	}
+}
+
+// registerRESTMethod registers op, the REST operation of a method, and returns the operation in the
+// OpenAPI document.
+func registerRESTMethod(
+	api huma.API,
+	op huma.Operation,
+	methodName string,
+	handler jsonrpcReqResp.IMethodHandler,
+	brh *jsonrpcReqResp.BatchRequestHandler,
+) *huma.Operation {
+	documentRESTMethod(api, &op, methodName, handler)
+	op.Middlewares = append(op.Middlewares, PeerInfoMiddleware(DefaultTransport))
+
+	RegisterWithErrorHandler(api, op, restErrorHandler, func(ctx context.Context, input *restInput) (*restOutput, error) {
+		request := jsonrpcReqResp.UnionRequest{
+			JSONRPC: jsonrpcReqResp.JSONRPCVersion,
+			ID:      &jsonrpcReqResp.RequestID{Value: "rest-" + strconv.FormatInt(restRequestIDs.Add(1), 10)},
+			Method:  &methodName,
+		}
+		if input.Body != nil {
+			request.Params = *input.Body
+		}
+		resp, err := brh.Handle(ctx, &jsonrpcReqResp.BatchRequest{
+			Body: &jsonrpcReqResp.BatchItem[jsonrpcReqResp.UnionRequest]{
+				Items: []jsonrpcReqResp.UnionRequest{request},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Body == nil || len(resp.Body.Items) == 0 {
+			return nil, NewRESTError(jsonrpcReqResp.JSONRPCError{
+				Code:    jsonrpcReqResp.InternalError,
+				Message: jsonrpcReqResp.GetDefaultErrorMessage(jsonrpcReqResp.InternalError),
+			})
+		}
+		item := resp.Body.Items[0]
+		if item.Error != nil {
+			return nil, NewRESTError(*item.Error)
+		}
+		result := item.Result
+		if len(result) == 0 {
+			result = json.RawMessage("null")
+		}
+		return &restOutput{Body: result}, nil
+	})
+	return api.OpenAPI().Paths[op.Path].Post
+}
+
+// documentRESTMethod sets the request and response bodies of op, the REST operation of a method, from
+// the types of handler. Huma validates the requests of registered operations with the request body
+// schema they were registered with, so it is updated in place.
+func documentRESTMethod(api huma.API, op *huma.Operation, methodName string, handler jsonrpcReqResp.IMethodHandler) {
+	paramsType, resultType := handler.GetTypes()
+	registry := api.OpenAPI().Components.Schemas
+	paramsSchema := registry.Schema(paramsType, true, methodName+"Params")
+	if op.RequestBody != nil && op.RequestBody.Content["application/json"] != nil &&
+		op.RequestBody.Content["application/json"].Schema != nil {
+		schema := op.RequestBody.Content["application/json"].Schema
+		*schema = *paramsSchema
+		schema.PrecomputeMessages()
+		paramsSchema = schema
+	}
+	op.RequestBody = &huma.RequestBody{
+		Required: !isNillableType(paramsType),
+		Content: map[string]*huma.MediaType{
+			"application/json": {
+				Schema:   paramsSchema,
+				Examples: getRESTExamples(methodName, GetExamples(paramsType)),
+			},
+		},
+	}
+	if op.Responses == nil {
+		op.Responses = map[string]*huma.Response{}
+	}
+	op.Responses["200"] = &huma.Response{
+		Description: http.StatusText(http.StatusOK),
+		Content: map[string]*huma.MediaType{
+			"application/json": {
+				Schema:   registry.Schema(resultType, true, methodName+"Result"),
+				Examples: getRESTExamples(methodName, GetExamples(resultType)),
+			},
+		},
+	}
+}
+
+// getRESTExamples returns the examples of a body of the REST operation of a method, named like the
+// examples of the JSONRPC operation.
+func getRESTExamples(methodName string, values []any) map[string]*huma.Example {
+	examples := make(map[string]*huma.Example, len(values))
+	for i, value := range values {
+		examples[exampleName(methodName, i)] = &huma.Example{Summary: methodName, Value: value}
+	}
+	return examples
+}
+
+// restErrorHandler converts the errors of the REST operations, e.g. a body not matching the params
+// schema, to a RESTError.
+func restErrorHandler(
+	_ huma.Context,
+	status int,
+	message string,
+	errs ...error,
+) huma.StatusError {
+	code := jsonrpcReqResp.InternalError
+	if status >= 400 && status < 500 {
+		code = jsonrpcReqResp.InvalidRequestError
+	}
+	var details []ErrorDetail
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var jsonRPCError jsonrpcReqResp.JSONRPCError
+		if detailer, ok := err.(huma.ErrorDetailer); ok {
+			d := detailer.ErrorDetail()
+			details = append(details, ErrorDetail{Location: d.Location, Message: d.Message})
+			switch {
+			case d.Location == "body" && status == http.StatusBadRequest:
+				code = jsonrpcReqResp.ParseError
+			case (d.Location == "body" || strings.HasPrefix(d.Location, "body.") ||
+				strings.HasPrefix(d.Location, "body[")) && code != jsonrpcReqResp.ParseError:
+				// The body is the params of the method.
+				code = jsonrpcReqResp.InvalidParamsError
+			}
+		} else if errors.As(err, &jsonRPCError) {
+			return NewRESTError(jsonRPCError)
+		} else {
+			details = append(details, ErrorDetail{Message: err.Error()})
+		}
+	}
+	jsonRPCError := jsonrpcReqResp.JSONRPCError{
+		Code:    code,
+		Message: jsonrpcReqResp.GetDefaultErrorMessage(code),
+	}
+	if len(details) > 0 {
+		jsonRPCError.Data = ErrorData{Errors: details}
+	}
+	return NewRESTError(jsonRPCError)
+}
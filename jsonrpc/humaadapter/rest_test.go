@@ -0,0 +1,156 @@
+package humaadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+func TestREST(t *testing.T) {
+	_, api := humatest.New(t)
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[AddParams, int]{
+		Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+			return params.A + params.B, nil
+		},
+	})
+	registry.RegisterMethod("math/fail", &jsonrpcReqResp.MethodHandler[*struct{}, *struct{}]{
+		Endpoint: func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+			return nil, errors.New("failed")
+		},
+	})
+	var called []string
+	middleware := func(next jsonrpcReqResp.IMethodHandler) jsonrpcReqResp.IMethodHandler {
+		return jsonrpcReqResp.WrapMethodHandler(next, func(
+			ctx context.Context,
+			req jsonrpcReqResp.Request[json.RawMessage],
+		) jsonrpcReqResp.Response[json.RawMessage] {
+			called = append(called, req.Method)
+			return next.Handle(ctx, req)
+		})
+	}
+	RegisterRegistry(api, GetDefaultOperation(), registry)
+	RegisterRegistryREST(api, DefaultRESTPrefix, registry, jsonrpcReqResp.WithMethodMiddleware(middleware))
+
+	for _, tc := range []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantCode   jsonrpcReqResp.JSONRPCErrorCode
+	}{
+		{"Result", "/rpc/add", `{"a": 1, "b": 2}`, http.StatusOK, "3", 0},
+		{"Invalid params", "/rpc/add", `{"a": "one", "b": 2}`, http.StatusUnprocessableEntity, "", jsonrpcReqResp.InvalidParamsError},
+		{"Invalid JSON", "/rpc/add", `{"a": 1`, http.StatusBadRequest, "", jsonrpcReqResp.ParseError},
+		{"Handler error", "/rpc/math/fail", ``, http.StatusInternalServerError, "", jsonrpcReqResp.InternalError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := api.Post(tc.path, strings.NewReader(tc.body))
+			if resp.Code != tc.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.wantStatus, resp.Code, resp.Body.String())
+			}
+			if tc.wantCode == 0 {
+				if got := strings.TrimSpace(resp.Body.String()); got != tc.wantBody {
+					t.Errorf("Expected body %s, got %s", tc.wantBody, got)
+				}
+				return
+			}
+			var out jsonrpcReqResp.JSONRPCError
+			if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil || out.Code != tc.wantCode {
+				t.Errorf("Expected error code %d, got %s", tc.wantCode, resp.Body.String())
+			}
+		})
+	}
+	if strings.Join(called, ",") != "add,math/fail" {
+		t.Errorf("Expected calls through the method middleware, got %v", called)
+	}
+
+	// The JSONRPC operation still serves the same handlers.
+	resp := api.Post("/jsonrpc", map[string]any{"jsonrpc": "2.0", "id": 1, "method": "add", "params": map[string]any{"a": 2, "b": 2}})
+	if !strings.Contains(resp.Body.String(), `"result":4`) {
+		t.Errorf("Unexpected JSONRPC response %s", resp.Body.String())
+	}
+
+	op := api.OpenAPI().Paths["/rpc/add"].Post
+	if op.OperationID != "rpc-add" || !op.RequestBody.Required ||
+		!strings.HasSuffix(op.RequestBody.Content["application/json"].Schema.Ref, "/AddParams") {
+		t.Errorf("Unexpected REST operation %+v", op)
+	}
+	if example := op.RequestBody.Content["application/json"].Examples["add"]; example == nil ||
+		example.Value != (AddParams{}) {
+		t.Errorf("Expected the params example, got %+v", example)
+	}
+	if example := op.Responses["200"].Content["application/json"].Examples["add"]; example == nil ||
+		example.Value != 0 {
+		t.Errorf("Expected the result example, got %+v", example)
+	}
+	if op := api.OpenAPI().Paths["/rpc/math/fail"].Post; op.RequestBody.Required {
+		t.Errorf("Expected optional body for pointer params")
+	}
+}
+
+func TestRESTFollowsRegistry(t *testing.T) {
+	_, api := humatest.New(t)
+	registry := jsonrpcReqResp.NewRegistry()
+	var ids []string
+	middleware := func(next jsonrpcReqResp.IMethodHandler) jsonrpcReqResp.IMethodHandler {
+		return jsonrpcReqResp.WrapMethodHandler(next, func(
+			ctx context.Context,
+			req jsonrpcReqResp.Request[json.RawMessage],
+		) jsonrpcReqResp.Response[json.RawMessage] {
+			ids = append(ids, req.ID.Key())
+			return next.Handle(ctx, req)
+		})
+	}
+	unsubscribe := RegisterRegistryREST(api, DefaultRESTPrefix, registry, jsonrpcReqResp.WithMethodMiddleware(middleware))
+	defer unsubscribe()
+
+	registry.RegisterMethod("sub", &jsonrpcReqResp.MethodHandler[AddParams, int]{
+		Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+			return params.A - params.B, nil
+		},
+	})
+	for range 2 {
+		if resp := api.Post("/rpc/sub", strings.NewReader(`{"a": 3, "b": 1}`)); strings.TrimSpace(resp.Body.String()) != "2" {
+			t.Fatalf("Unexpected response %d: %s", resp.Code, resp.Body.String())
+		}
+	}
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Errorf("Expected a request ID per call, got %v", ids)
+	}
+	if api.OpenAPI().Paths["/rpc/sub"] == nil {
+		t.Fatal("Expected the operation of sub to be documented")
+	}
+
+	registry.UnregisterMethod("sub")
+	if resp := api.Post("/rpc/sub", strings.NewReader(`{"a": 3, "b": 1}`)); resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after unregistering, got %d", http.StatusNotFound, resp.Code)
+	}
+	if api.OpenAPI().Paths["/rpc/sub"] != nil {
+		t.Error("Expected the operation of sub to be removed from the document")
+	}
+
+	// Re-registered methods are validated with their new params.
+	registry.RegisterMethod("sub", &jsonrpcReqResp.MethodHandler[ScaleParams, int]{
+		Endpoint: func(ctx context.Context, params ScaleParams) (int, error) {
+			return params.Value - params.Factor, nil
+		},
+	})
+	if resp := api.Post("/rpc/sub", strings.NewReader(`{"value": 5, "factor": 1}`)); strings.TrimSpace(resp.Body.String()) != "4" {
+		t.Errorf("Unexpected response %d: %s", resp.Code, resp.Body.String())
+	}
+	if resp := api.Post("/rpc/sub", strings.NewReader(`{"a": 3, "b": 1}`)); resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected the old params to be invalid, got %d: %s", resp.Code, resp.Body.String())
+	}
+	op := api.OpenAPI().Paths["/rpc/sub"]
+	if op == nil || !strings.HasSuffix(op.Post.RequestBody.Content["application/json"].Schema.Ref, "/ScaleParams") {
+		t.Errorf("Expected the operation of sub to be documented with its new params, got %+v", op)
+	}
+}
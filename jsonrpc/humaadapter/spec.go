@@ -0,0 +1,260 @@
+package humaadapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/danielgtaylor/huma/v2"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// schemaRefPrefix is the prefix of the references to the component schemas.
+const schemaRefPrefix = "#/components/schemas/"
+
+var schemaRefPattern = regexp.MustCompile(`"\$ref":"` + regexp.QuoteMeta(schemaRefPrefix) + `([^"]+)"`)
+
+// apiSpec guards the OpenAPI document of an API created with NewAPI, which is updated as handlers are
+// registered or unregistered while it is served. Updates following registries are applied when the
+// document is served, so that registering many handlers does not rebuild it each time.
+type apiSpec struct {
+	mu sync.Mutex
+	// generated holds the names of the component schemas added by updates, which are removed once
+	// nothing refers to them.
+	generated map[string]bool
+	// kept holds the names of generated schemas that are kept even if nothing refers to them yet,
+	// e.g. the base schemas of a JSONRPC operation that is not registered yet.
+	kept map[string]bool
+	// pending holds the updates of the registries that changed since the document was last served,
+	// by follower ID.
+	pending map[int]func(spec *apiSpec)
+	nextID  int
+	// stale is set when schemas may have to be removed.
+	stale bool
+}
+
+func newAPISpec() *apiSpec {
+	return &apiSpec{
+		generated: map[string]bool{},
+		kept:      map[string]bool{},
+		pending:   map[int]func(spec *apiSpec){},
+	}
+}
+
+// fallbackSpecMu serializes the updates of the documents of APIs not created with NewAPI.
+var fallbackSpecMu sync.Mutex
+
+// getAPISpec returns the apiSpec of an API created with NewAPI, or nil.
+func getAPISpec(api huma.API) *apiSpec {
+	if adapter, ok := api.Adapter().(*specAdapter); ok {
+		return adapter.spec
+	}
+	return nil
+}
+
+// updateSpec runs update holding the lock of the OpenAPI document of api, so that it is not served
+// while being updated. For APIs created with NewAPI, the component schemas added by updates and no
+// longer referred to are removed when the document is next served. For other APIs, spec is nil.
+func updateSpec(api huma.API, update func(spec *apiSpec)) {
+	spec := getAPISpec(api)
+	if spec == nil {
+		fallbackSpecMu.Lock()
+		defer fallbackSpecMu.Unlock()
+		update(nil)
+		return
+	}
+	spec.mu.Lock()
+	defer spec.mu.Unlock()
+	spec.apply(api.OpenAPI(), update)
+}
+
+// followRegistry runs update, and again after each change of the method or notification handlers of
+// registry. For APIs created with NewAPI, the update after changes is deferred until the document is
+// served. The returned function stops following the registry.
+func followRegistry(api huma.API, registry HandlerSource, update func(spec *apiSpec)) (unsubscribe func()) {
+	updateSpec(api, update)
+	spec := getAPISpec(api)
+	if spec == nil {
+		return registry.Subscribe(func(change jsonrpcReqResp.RegistryChange) {
+			if change.Kind != jsonrpcReqResp.HandlerKindResponse {
+				updateSpec(api, update)
+			}
+		})
+	}
+
+	spec.mu.Lock()
+	id := spec.nextID
+	spec.nextID++
+	spec.mu.Unlock()
+	unsubscribeRegistry := registry.Subscribe(func(change jsonrpcReqResp.RegistryChange) {
+		if change.Kind == jsonrpcReqResp.HandlerKindResponse {
+			return
+		}
+		spec.mu.Lock()
+		spec.pending[id] = update
+		spec.mu.Unlock()
+	})
+	return func() {
+		unsubscribeRegistry()
+		spec.mu.Lock()
+		delete(spec.pending, id)
+		spec.mu.Unlock()
+	}
+}
+
+// apply runs update, recording the schemas it adds as generated. The lock must be held.
+func (s *apiSpec) apply(oapi *huma.OpenAPI, update func(spec *apiSpec)) {
+	schemas := oapi.Components.Schemas.Map()
+	existing := make(map[string]bool, len(schemas))
+	for name := range schemas {
+		existing[name] = true
+	}
+	update(s)
+	for name := range schemas {
+		if !existing[name] {
+			s.generated[name] = true
+		}
+	}
+	s.stale = true
+}
+
+// refresh applies the pending updates and removes the schemas no longer referred to. The lock must be
+// held.
+func (s *apiSpec) refresh(oapi *huma.OpenAPI) {
+	for id, update := range s.pending {
+		s.apply(oapi, update)
+		delete(s.pending, id)
+	}
+	if s.stale {
+		s.removeUnreferencedSchemas(oapi)
+		s.stale = false
+	}
+}
+
+// keep marks the schema of ref as kept, see apiSpec.kept. It does nothing for a nil apiSpec.
+func (s *apiSpec) keep(ref string) {
+	if s != nil && len(ref) > len(schemaRefPrefix) {
+		s.kept[ref[len(schemaRefPrefix):]] = true
+	}
+}
+
+// removeUnreferencedSchemas removes the generated schemas that are not referred to, directly or through
+// other schemas, by the document or by schemas that are not generated.
+// Removed schemas are generated again by the registry of the API if their type is used again.
+func (s *apiSpec) removeUnreferencedSchemas(oapi *huma.OpenAPI) {
+	schemas := oapi.Components.Schemas.Map()
+
+	// Find the references of the document without its schemas.
+	doc := *oapi
+	if oapi.Components != nil {
+		components := *oapi.Components
+		components.Schemas = huma.NewMapRegistry(schemaRefPrefix, huma.DefaultSchemaNamer)
+		doc.Components = &components
+	}
+	pending := schemaRefs(&doc)
+	for name := range schemas {
+		if !s.generated[name] || s.kept[name] {
+			pending = append(pending, name)
+		}
+	}
+
+	referenced := map[string]bool{}
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if referenced[name] {
+			continue
+		}
+		referenced[name] = true
+		if schema := schemas[name]; schema != nil {
+			pending = append(pending, schemaRefs(schema)...)
+		}
+	}
+	for name := range s.generated {
+		if !referenced[name] {
+			delete(schemas, name)
+			delete(s.generated, name)
+		}
+	}
+}
+
+// schemaRefs returns the names of the component schemas referred to in v.
+func schemaRefs(v any) []string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, match := range schemaRefPattern.FindAllSubmatch(b, -1) {
+		names = append(names, string(match[1]))
+	}
+	return names
+}
+
+// NewAPI creates a huma API like huma.NewAPI, whose OpenAPI document routes serve the current document.
+// huma.NewAPI serves the document as marshalled on its first request, so the schemas and examples of
+// handlers registered or unregistered later, e.g. with RegisterRegistry, are not served.
+//
+// The document follows the registries when served: the schemas and examples of changed registries are
+// rebuilt, and the schemas of removed handlers are dropped. Until then, api.OpenAPI() may describe the
+// handlers as they were when last served. With APIs created otherwise, the document is rebuilt on each
+// change, and the schemas of removed handlers are kept.
+//
+// Example:
+//
+//	router := http.NewServeMux()
+//	api := humaadapter.NewAPI(huma.DefaultConfig("My API", "1.0.0"), humago.NewAdapter(router, ""))
+func NewAPI(config huma.Config, adapter huma.Adapter) huma.API {
+	specAdapter := &specAdapter{Adapter: adapter, openAPIPath: config.OpenAPIPath, spec: newAPISpec()}
+	api := huma.NewAPI(config, specAdapter)
+	specAdapter.api = api
+	return api
+}
+
+// specAdapter replaces the handlers of the OpenAPI document routes registered by huma.NewAPI.
+type specAdapter struct {
+	huma.Adapter
+	openAPIPath string
+	spec        *apiSpec
+	// api is set once created, before serving.
+	api huma.API
+}
+
+func (a *specAdapter) Handle(op *huma.Operation, handler func(huma.Context)) {
+	if op.Method == http.MethodGet && a.openAPIPath != "" {
+		switch op.Path {
+		case a.openAPIPath + ".json":
+			handler = a.serveSpec("application/vnd.oai.openapi+json", func(oapi *huma.OpenAPI) ([]byte, error) {
+				return json.Marshal(oapi)
+			})
+		case a.openAPIPath + "-3.0.json":
+			handler = a.serveSpec("application/vnd.oai.openapi+json", (*huma.OpenAPI).Downgrade)
+		case a.openAPIPath + ".yaml":
+			handler = a.serveSpec("application/vnd.oai.openapi+yaml", (*huma.OpenAPI).YAML)
+		case a.openAPIPath + "-3.0.yaml":
+			handler = a.serveSpec("application/vnd.oai.openapi+yaml", (*huma.OpenAPI).DowngradeYAML)
+		}
+	}
+	a.Adapter.Handle(op, handler)
+}
+
+// serveSpec returns the handler serving the document marshalled by marshal.
+func (a *specAdapter) serveSpec(
+	contentType string,
+	marshal func(*huma.OpenAPI) ([]byte, error),
+) func(huma.Context) {
+	return func(ctx huma.Context) {
+		oapi := a.api.OpenAPI()
+		a.spec.mu.Lock()
+		a.spec.refresh(oapi)
+		b, err := marshal(oapi)
+		a.spec.mu.Unlock()
+		if err != nil {
+			ctx.SetStatus(http.StatusInternalServerError)
+			return
+		}
+		ctx.SetHeader("Content-Type", contentType)
+		_, _ = ctx.BodyWriter().Write(b)
+	}
+}
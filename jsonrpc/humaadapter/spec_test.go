@@ -0,0 +1,125 @@
+package humaadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+type ScaleParams struct {
+	Value  int `json:"value"`
+	Factor int `json:"factor"`
+}
+
+func TestServedSpecFollowsRegistry(t *testing.T) {
+	api := humatest.Wrap(t, NewAPI(huma.DefaultConfig("Test API", "1.0.0"), humatest.NewAdapter()))
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[AddParams, int]{
+		Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+			return params.A + params.B, nil
+		},
+	})
+	RegisterRegistry(api, GetDefaultOperation(), registry)
+	scale := &jsonrpcReqResp.MethodHandler[ScaleParams, int]{
+		Endpoint: func(ctx context.Context, params ScaleParams) (int, error) {
+			return params.Value * params.Factor, nil
+		},
+	}
+
+	getSchemas := func(t *testing.T) map[string]json.RawMessage {
+		t.Helper()
+		resp := api.Get("/openapi.json")
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Unexpected status %d: %s", resp.Code, resp.Body.String())
+		}
+		var doc struct {
+			Components struct {
+				Schemas map[string]json.RawMessage `json:"schemas"`
+			} `json:"components"`
+		}
+		if err := json.Unmarshal(resp.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("Invalid document: %v", err)
+		}
+		return doc.Components.Schemas
+	}
+	// The first fetch would be cached by huma.
+	if schemas := getSchemas(t); schemas["AddRequest"] == nil || schemas["ScaleRequest"] != nil {
+		t.Fatalf("Unexpected schemas before registering")
+	}
+
+	registry.RegisterMethod("scale", scale)
+	schemas := getSchemas(t)
+	for _, name := range []string{"AddRequest", "ScaleRequest", "ScaleParams", "ScaleSuccessResponse"} {
+		if schemas[name] == nil {
+			t.Errorf("Expected schema %s after registering", name)
+		}
+	}
+	if !strings.Contains(string(schemas["RequestValue"]), "ScaleRequest") {
+		t.Errorf("Expected the request oneOf to hold scale, got %s", schemas["RequestValue"])
+	}
+	for _, path := range []string{"/openapi.yaml", "/openapi-3.0.json", "/openapi-3.0.yaml"} {
+		if resp := api.Get(path); !strings.Contains(resp.Body.String(), "ScaleRequest") {
+			t.Errorf("Expected %s to hold scale", path)
+		}
+	}
+
+	registry.UnregisterMethod("scale")
+	schemas = getSchemas(t)
+	for _, name := range []string{"ScaleRequest", "ScaleParams", "ScaleSuccessResponse", "ScaleErrorResponse"} {
+		if schemas[name] != nil {
+			t.Errorf("Expected schema %s to be removed after unregistering", name)
+		}
+	}
+	if schemas["AddRequest"] == nil || schemas["AddParams"] == nil {
+		t.Errorf("Expected the schemas of add to be kept")
+	}
+
+	// Removed schemas are generated again.
+	registry.RegisterMethod("scale", scale)
+	if schemas := getSchemas(t); schemas["ScaleRequest"] == nil || schemas["ScaleParams"] == nil {
+		t.Errorf("Expected the schemas of scale after registering again")
+	}
+}
+
+func TestSpecUpdatedWhenServed(t *testing.T) {
+	api := humatest.Wrap(t, NewAPI(huma.DefaultConfig("Test API", "1.0.0"), humatest.NewAdapter()))
+	registry := jsonrpcReqResp.NewRegistry()
+	unsubscribe := AddRegistrySchemasToAPI(api, registry)
+	spec := getAPISpec(api)
+	if spec == nil {
+		t.Fatal("Expected the state of the document on the adapter")
+	}
+
+	for _, name := range []string{"add", "sum", "plus"} {
+		registry.RegisterMethod(name, &jsonrpcReqResp.MethodHandler[AddParams, int]{
+			Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+				return params.A + params.B, nil
+			},
+		})
+	}
+	if _, ok := api.OpenAPI().Components.Schemas.Map()["PlusRequest"]; ok {
+		t.Error("Expected the document to be updated when served, not on each registration")
+	}
+	if len(spec.pending) != 1 {
+		t.Errorf("Expected one pending update for the registry, got %d", len(spec.pending))
+	}
+
+	if resp := api.Get("/openapi.json"); !strings.Contains(resp.Body.String(), "PlusRequest") {
+		t.Error("Expected the served document to hold plus")
+	}
+	if len(spec.pending) != 0 {
+		t.Errorf("Expected no pending update once served, got %d", len(spec.pending))
+	}
+
+	unsubscribe()
+	registry.UnregisterMethod("plus")
+	if len(spec.pending) != 0 {
+		t.Error("Expected no update once unsubscribed")
+	}
+}
@@ -0,0 +1,333 @@
+// Package openrpc generates OpenRPC documents describing the handlers of a JSONRPC registry or router.
+//
+// The document can be served with the rpc.discover method, so that transports without an OpenAPI
+// description, e.g. stdio, can describe themselves.
+//
+// Example:
+//
+//	registry := reqresp.NewRegistry()
+//	registry.RegisterMethod("add", &reqresp.MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint})
+//	openrpc.RegisterDiscover(registry, openrpc.WithInfo(openrpc.Info{Title: "Calculator", Version: "1.0.0"}))
+package openrpc
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+const (
+	// Version is the version of the OpenRPC specification of generated documents.
+	Version = "1.3.2"
+
+	// DiscoverMethod is the name of the method returning the document of a server.
+	DiscoverMethod = "rpc.discover"
+
+	// SchemaRefPrefix is the prefix of the references to the schemas in the components of a document.
+	SchemaRefPrefix = "#/components/schemas/"
+)
+
+// Values of Method.ParamStructure.
+const (
+	ParamStructureByName     = "by-name"
+	ParamStructureByPosition = "by-position"
+	ParamStructureEither     = "either"
+)
+
+// Document is an OpenRPC document.
+type Document struct {
+	OpenRPC    string      `json:"openrpc"`
+	Info       Info        `json:"info"`
+	Servers    []Server    `json:"servers,omitempty"`
+	Methods    []Method    `json:"methods"`
+	Components *Components `json:"components,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server describes a server serving the API.
+type Server struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Method describes a method or a notification. Notifications have no result.
+type Method struct {
+	Name           string              `json:"name"`
+	Summary        string              `json:"summary,omitempty"`
+	Description    string              `json:"description,omitempty"`
+	Params         []ContentDescriptor `json:"params"`
+	Result         *ContentDescriptor  `json:"result,omitempty"`
+	Errors         []Error             `json:"errors,omitempty"`
+	ParamStructure string              `json:"paramStructure,omitempty"`
+}
+
+// ContentDescriptor describes a param or a result.
+type ContentDescriptor struct {
+	Name     string       `json:"name"`
+	Required bool         `json:"required,omitempty"`
+	Schema   *huma.Schema `json:"schema"`
+}
+
+// Error describes an error a method can return.
+type Error struct {
+	Code    jsonrpcReqResp.JSONRPCErrorCode `json:"code"`
+	Message string                          `json:"message"`
+}
+
+// Components holds the schemas referenced by the methods.
+type Components struct {
+	Schemas map[string]*huma.Schema `json:"schemas,omitempty"`
+}
+
+// Source provides the handlers described by a document.
+// It is implemented by reqresp.Registry and reqresp.Router.
+type Source interface {
+	MethodMap() map[string]jsonrpcReqResp.IMethodHandler
+	NotificationMap() map[string]jsonrpcReqResp.INotificationHandler
+}
+
+// Option configures the generated document.
+type Option func(*config)
+
+type config struct {
+	info         Info
+	servers      []Server
+	errors       []jsonrpcReqResp.JSONRPCErrorCode
+	methodErrors map[string][]jsonrpcReqResp.JSONRPCErrorCode
+}
+
+// WithInfo sets the info of the document. The default title is "JSONRPC API" and version "1.0.0".
+func WithInfo(info Info) Option {
+	return func(c *config) {
+		c.info = info
+	}
+}
+
+// WithServers sets the servers of the document.
+func WithServers(servers ...Server) Option {
+	return func(c *config) {
+		c.servers = append(c.servers, servers...)
+	}
+}
+
+// WithErrors declares error codes that all methods can return.
+// Messages are the default messages of the codes, see reqresp.RegisterErrorCode.
+func WithErrors(codes ...jsonrpcReqResp.JSONRPCErrorCode) Option {
+	return func(c *config) {
+		c.errors = append(c.errors, codes...)
+	}
+}
+
+// WithMethodErrors declares error codes that method can return.
+// Messages are the default messages of the codes, see reqresp.RegisterErrorCode.
+func WithMethodErrors(method string, codes ...jsonrpcReqResp.JSONRPCErrorCode) Option {
+	return func(c *config) {
+		c.methodErrors[method] = append(c.methodErrors[method], codes...)
+	}
+}
+
+// Generate returns the document describing the methods and notifications of source, sorted by name.
+// The rpc.discover method itself is not described.
+//
+// Params of a struct type are described by field, and can be sent by name, or also by position
+// if the struct has `jsonrpc:"pos=N"` tags. Params of other types are described as a single
+// content descriptor named "params", holding the schema of the whole params value.
+func Generate(source Source, opts ...Option) *Document {
+	cfg := &config{
+		info:         Info{Title: "JSONRPC API", Version: "1.0.0"},
+		methodErrors: make(map[string][]jsonrpcReqResp.JSONRPCErrorCode),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	registry := huma.NewMapRegistry(SchemaRefPrefix, huma.DefaultSchemaNamer)
+	methods := make([]Method, 0)
+	for name, handler := range source.MethodMap() {
+		if name == DiscoverMethod {
+			continue
+		}
+		iType, oType := handler.GetTypes()
+		method := describeParams(registry, name, iType)
+		method.Result = &ContentDescriptor{
+			Name:     "result",
+			Required: !isNillableType(oType),
+			Schema:   registry.Schema(oType, true, name+"Result"),
+		}
+		method.Errors = cfg.describeErrors(name)
+		methods = append(methods, method)
+	}
+	for name, handler := range source.NotificationMap() {
+		method := describeParams(registry, name, handler.GetTypes())
+		method.Errors = cfg.describeErrors(name)
+		methods = append(methods, method)
+	}
+	slices.SortFunc(methods, func(a, b Method) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	doc := &Document{
+		OpenRPC: Version,
+		Info:    cfg.info,
+		Servers: cfg.servers,
+		Methods: methods,
+	}
+	if schemas := registry.Map(); len(schemas) > 0 {
+		doc.Components = &Components{Schemas: schemas}
+	}
+	return doc
+}
+
+// DiscoverHandler returns a handler for the rpc.discover method, describing the current content
+// of source on each call.
+func DiscoverHandler(source Source, opts ...Option) jsonrpcReqResp.IMethodHandler {
+	return &jsonrpcReqResp.MethodHandler[*struct{}, *Document]{
+		Endpoint: func(ctx context.Context, _ *struct{}) (*Document, error) {
+			return Generate(source, opts...), nil
+		},
+	}
+}
+
+// RegisterDiscover registers the rpc.discover method in registry, describing the registry itself.
+func RegisterDiscover(registry *jsonrpcReqResp.Registry, opts ...Option) {
+	registry.RegisterMethod(DiscoverMethod, DiscoverHandler(registry, opts...))
+}
+
+// describeParams returns a method with the params and param structure of iType.
+func describeParams(registry huma.Registry, name string, iType reflect.Type) Method {
+	method := Method{Name: name, Params: make([]ContentDescriptor, 0)}
+	t := iType
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		method.Params = append(method.Params, ContentDescriptor{
+			Name:     "params",
+			Required: !isNillableType(iType),
+			Schema:   registry.Schema(iType, true, name+"Params"),
+		})
+		return method
+	}
+
+	schema := registry.Schema(t, true, name+"Params")
+	if schema.Ref != "" {
+		schema = registry.SchemaFromRef(schema.Ref)
+	}
+	for _, param := range paramNames(t, schema) {
+		method.Params = append(method.Params, ContentDescriptor{
+			Name:     param,
+			Required: slices.Contains(schema.Required, param),
+			Schema:   schema.Properties[param],
+		})
+	}
+	method.ParamStructure = ParamStructureByName
+	if _, _, ok := jsonrpcReqResp.PositionalArity(t); ok {
+		method.ParamStructure = ParamStructureEither
+	}
+	return method
+}
+
+// paramNames returns the names of the properties of schema, generated for the struct t.
+// Positional fields come first, by position, followed by the other fields in declaration order.
+func paramNames(t reflect.Type, schema *huma.Schema) []string {
+	type namedField struct {
+		name string
+		pos  int
+	}
+	var fields []namedField
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			if field.Anonymous && name == "" {
+				ft := field.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					collect(ft)
+				}
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fields = append(fields, namedField{name: name, pos: position(field)})
+		}
+	}
+	collect(t)
+	sort.SliceStable(fields, func(i, j int) bool {
+		pi, pj := fields[i].pos, fields[j].pos
+		return pi >= 0 && (pj < 0 || pi < pj)
+	})
+
+	names := make([]string, 0, len(schema.Properties))
+	for _, f := range fields {
+		if _, ok := schema.Properties[f.name]; ok && !slices.Contains(names, f.name) {
+			names = append(names, f.name)
+		}
+	}
+	// Properties not backed by a field, if any, follow in name order.
+	var rest []string
+	for name := range schema.Properties {
+		if !slices.Contains(names, name) {
+			rest = append(rest, name)
+		}
+	}
+	slices.Sort(rest)
+	return append(names, rest...)
+}
+
+// position returns the position of a field from its `jsonrpc:"pos=N"` tag, -1 if it has none.
+func position(field reflect.StructField) int {
+	for opt := range strings.SplitSeq(field.Tag.Get("jsonrpc"), ",") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(opt), "pos="); found {
+			if pos, err := strconv.Atoi(value); err == nil && pos >= 0 {
+				return pos
+			}
+		}
+	}
+	return -1
+}
+
+// describeErrors returns the errors declared for method.
+func (c *config) describeErrors(method string) []Error {
+	codes := append(slices.Clone(c.errors), c.methodErrors[method]...)
+	errs := make([]Error, 0, len(codes))
+	for _, code := range codes {
+		if slices.ContainsFunc(errs, func(e Error) bool { return e.Code == code }) {
+			continue
+		}
+		errs = append(errs, Error{Code: code, Message: jsonrpcReqResp.GetDefaultErrorMessage(code)})
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func isNillableType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,153 @@
+package openrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/ppipada/go-mcp-expt/jsonrpc/humaadapter"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+type AddParams struct {
+	B int  `json:"b"           jsonrpc:"pos=1"`
+	A int  `json:"a"           jsonrpc:"pos=0"`
+	C *int `json:"c,omitempty"`
+}
+
+type AddResult struct {
+	Sum int `json:"sum"`
+}
+
+type LogParams struct {
+	Message string `json:"message"`
+}
+
+func getRegistry() *jsonrpcReqResp.Registry {
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[AddParams, AddResult]{
+		Endpoint: func(ctx context.Context, params AddParams) (AddResult, error) {
+			return AddResult{Sum: params.A + params.B}, nil
+		},
+	})
+	registry.RegisterMethod("sum", &jsonrpcReqResp.MethodHandler[[]int, *int]{
+		Endpoint: func(ctx context.Context, params []int) (*int, error) {
+			return nil, nil
+		},
+	})
+	registry.RegisterNotification("log", &jsonrpcReqResp.NotificationHandler[LogParams]{
+		Endpoint: func(ctx context.Context, params LogParams) error {
+			return nil
+		},
+	})
+	return registry
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate(
+		getRegistry(),
+		WithInfo(Info{Title: "Calculator", Version: "2.0.0"}),
+		WithErrors(jsonrpcReqResp.InternalError),
+		WithMethodErrors("add", jsonrpcReqResp.InvalidParamsError, jsonrpcReqResp.InternalError),
+	)
+	if doc.OpenRPC != Version || doc.Info.Title != "Calculator" || doc.Info.Version != "2.0.0" {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+	names := make([]string, 0, len(doc.Methods))
+	for _, m := range doc.Methods {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "add,log,sum" {
+		t.Fatalf("Expected methods sorted by name, got %v", names)
+	}
+
+	add := doc.Methods[0]
+	if add.ParamStructure != ParamStructureEither {
+		t.Errorf("Expected positional params to be accepted, got %q", add.ParamStructure)
+	}
+	if len(add.Params) != 3 || add.Params[0].Name != "a" || add.Params[1].Name != "b" ||
+		add.Params[2].Name != "c" {
+		t.Fatalf("Expected params in positional order, got %+v", add.Params)
+	}
+	if !add.Params[0].Required || add.Params[2].Required {
+		t.Errorf("Unexpected required params: %+v", add.Params)
+	}
+	if add.Result == nil || !add.Result.Required ||
+		add.Result.Schema.Ref != SchemaRefPrefix+"AddResult" {
+		t.Errorf("Unexpected result: %+v", add.Result)
+	}
+	if len(add.Errors) != 2 || add.Errors[0].Code != jsonrpcReqResp.InternalError ||
+		add.Errors[1].Code != jsonrpcReqResp.InvalidParamsError ||
+		add.Errors[1].Message != jsonrpcReqResp.GetDefaultErrorMessage(jsonrpcReqResp.InvalidParamsError) {
+		t.Errorf("Unexpected errors: %+v", add.Errors)
+	}
+
+	log := doc.Methods[1]
+	if log.Result != nil || log.ParamStructure != ParamStructureByName ||
+		len(log.Params) != 1 || log.Params[0].Name != "message" {
+		t.Errorf("Unexpected notification: %+v", log)
+	}
+
+	sum := doc.Methods[2]
+	if len(sum.Params) != 1 || sum.Params[0].Name != "params" || sum.Params[0].Schema.Type != "array" {
+		t.Errorf("Expected array params to be described as a whole, got %+v", sum.Params)
+	}
+	if sum.Result.Required {
+		t.Errorf("Expected nullable result not to be required")
+	}
+
+	if doc.Components == nil || doc.Components.Schemas["AddResult"] == nil {
+		t.Errorf("Expected referenced schemas in components")
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Errorf("Document does not encode: %v", err)
+	}
+}
+
+func TestDiscoverOverHTTP(t *testing.T) {
+	_, api := humatest.New(t)
+	registry := getRegistry()
+	RegisterDiscover(registry)
+	humaadapter.RegisterRegistry(api, humaadapter.GetDefaultOperation(), registry)
+
+	resp := api.Post("/jsonrpc", map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  DiscoverMethod,
+	})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", resp.Code, resp.Body.String())
+	}
+	var out struct {
+		Result struct {
+			OpenRPC string `json:"openrpc"`
+			Methods []struct {
+				Name string `json:"name"`
+			} `json:"methods"`
+		} `json:"result"`
+		Error *jsonrpcReqResp.JSONRPCError `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if out.Error != nil {
+		t.Fatalf("Unexpected error: %+v", out.Error)
+	}
+	if out.Result.OpenRPC != Version || len(out.Result.Methods) != 3 {
+		t.Errorf("Expected rpc.discover not to describe itself, got %+v", out.Result.Methods)
+	}
+
+	// Methods registered later are described.
+	registry.RegisterMethod("noop", &jsonrpcReqResp.MethodHandler[*struct{}, *struct{}]{
+		Endpoint: func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+			return nil, nil
+		},
+	})
+	doc := Generate(registry)
+	if len(doc.Methods) != 4 || len(doc.Methods[2].Params) != 0 {
+		t.Errorf("Expected registered method to be described without params, got %+v", doc.Methods)
+	}
+}
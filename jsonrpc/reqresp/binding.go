@@ -0,0 +1,154 @@
+package reqresp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Params of a struct type can be sent either by name, as an object, or by position, as an array.
+// Positions are declared with the `jsonrpc` struct tag:
+//
+//	type AddParams struct {
+//	    A int  `json:"a" jsonrpc:"pos=0"`
+//	    B int  `json:"b" jsonrpc:"pos=1"`
+//	    C *int `json:"c" jsonrpc:"pos=2"`
+//	}
+//
+// With this, both {"a":1,"b":2} and [1,2] are accepted. Positions must start at 0 and be contiguous.
+// Trailing positions with pointer fields are optional, all others are required.
+
+const positionalTagKey = "jsonrpc"
+
+// positionalLayout describes how array params are bound to the fields of a struct.
+type positionalLayout struct {
+	// Field indexes, ordered by position.
+	fields [][]int
+	// Minimum number of positional params that must be present.
+	minArity int
+	// Set if the tags of the struct are invalid.
+	err error
+}
+
+// positionalLayouts caches the layout per struct type, nil if the type has no positional fields.
+var positionalLayouts sync.Map
+
+// PositionalArity reports the minimum and maximum number of positional params accepted by t.
+// The ok result is false if t does not accept positional params via `jsonrpc:"pos=N"` tags.
+func PositionalArity(t reflect.Type) (minArity, maxArity int, ok bool) {
+	layout := getPositionalLayout(t)
+	if layout == nil || layout.err != nil {
+		return 0, 0, false
+	}
+	return layout.minArity, len(layout.fields), true
+}
+
+func getPositionalLayout(t reflect.Type) *positionalLayout {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if cached, ok := positionalLayouts.Load(t); ok {
+		layout, _ := cached.(*positionalLayout)
+		return layout
+	}
+	layout := buildPositionalLayout(t)
+	positionalLayouts.Store(t, layout)
+	return layout
+}
+
+func buildPositionalLayout(t reflect.Type) *positionalLayout {
+	byPosition := make(map[int]reflect.StructField)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup(positionalTagKey)
+		if !ok || !field.IsExported() {
+			continue
+		}
+		for opt := range strings.SplitSeq(tag, ",") {
+			value, found := strings.CutPrefix(strings.TrimSpace(opt), "pos=")
+			if !found {
+				continue
+			}
+			pos, err := strconv.Atoi(value)
+			if err != nil || pos < 0 {
+				return &positionalLayout{
+					err: fmt.Errorf("invalid position %q on field %s.%s", value, t.Name(), field.Name),
+				}
+			}
+			if _, exists := byPosition[pos]; exists {
+				return &positionalLayout{
+					err: fmt.Errorf("duplicate position %d on field %s.%s", pos, t.Name(), field.Name),
+				}
+			}
+			byPosition[pos] = field
+		}
+	}
+	if len(byPosition) == 0 {
+		return nil
+	}
+
+	layout := &positionalLayout{fields: make([][]int, len(byPosition))}
+	for pos := range len(byPosition) {
+		field, ok := byPosition[pos]
+		if !ok {
+			return &positionalLayout{err: fmt.Errorf("positions of %s are not contiguous from 0", t.Name())}
+		}
+		layout.fields[pos] = field.Index
+		if field.Type.Kind() != reflect.Pointer {
+			layout.minArity = pos + 1
+		}
+	}
+	return layout
+}
+
+// bindPositional binds the elements of a JSON array to the positional fields of target.
+// Target must be a pointer to a struct, possibly through further pointers.
+func bindPositional(codec Codec, data json.RawMessage, target any, layout *positionalLayout) error {
+	if layout.err != nil {
+		return layout.err
+	}
+	var elems []json.RawMessage
+	if err := codec.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	maxArity := len(layout.fields)
+	if len(elems) < layout.minArity || len(elems) > maxArity {
+		if layout.minArity == maxArity {
+			return fmt.Errorf("expected %d positional params, got %d", maxArity, len(elems))
+		}
+		return fmt.Errorf(
+			"expected %d to %d positional params, got %d",
+			layout.minArity,
+			maxArity,
+			len(elems),
+		)
+	}
+
+	v := reflect.ValueOf(target).Elem()
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	for pos, elem := range elems {
+		field := v.FieldByIndex(layout.fields[pos])
+		if err := codec.Unmarshal(elem, field.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid param at position %d: %w", pos, err)
+		}
+	}
+	return nil
+}
+
+// isJSONArray reports whether data holds a JSON array.
+func isJSONArray(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '['
+}
@@ -0,0 +1,114 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type positionalAddParams struct {
+	A int  `json:"a"           jsonrpc:"pos=0"`
+	B int  `json:"b"           jsonrpc:"pos=1"`
+	C *int `json:"c,omitempty" jsonrpc:"pos=2"`
+}
+
+type invalidPositionalParams struct {
+	A int `json:"a" jsonrpc:"pos=0"`
+	B int `json:"b" jsonrpc:"pos=2"`
+}
+
+func TestPositionalBinding(t *testing.T) {
+	sum := func(ctx context.Context, params positionalAddParams) (AddResult, error) {
+		res := params.A + params.B
+		if params.C != nil {
+			res += *params.C
+		}
+		return AddResult{Sum: res}, nil
+	}
+	brh := NewBatchRequestHandler(WithMethodMap(map[string]IMethodHandler{
+		"add": &MethodHandler[positionalAddParams, AddResult]{Endpoint: sum},
+		"addOptional": &MethodHandler[*positionalAddParams, AddResult]{
+			Endpoint: func(ctx context.Context, params *positionalAddParams) (AddResult, error) {
+				if params == nil {
+					return AddResult{}, nil
+				}
+				return sum(ctx, *params)
+			},
+		},
+		"invalid": &MethodHandler[invalidPositionalParams, AddResult]{
+			Endpoint: func(ctx context.Context, params invalidPositionalParams) (AddResult, error) {
+				return AddResult{}, nil
+			},
+		},
+	}))
+
+	tests := []struct {
+		name          string
+		method        string
+		params        string
+		wantResult    string
+		wantErrSubstr string
+	}{
+		{name: "Named params", method: "add", params: `{"a":1,"b":2}`, wantResult: `{"sum":3}`},
+		{name: "Positional params", method: "add", params: `[1,2]`, wantResult: `{"sum":3}`},
+		{name: "Optional trailing positional param", method: "add", params: `[1,2,3]`, wantResult: `{"sum":6}`},
+		{name: "Positional params into pointer type", method: "addOptional", params: `[1,2]`, wantResult: `{"sum":3}`},
+		{
+			name:          "Too few positional params",
+			method:        "add",
+			params:        `[1]`,
+			wantErrSubstr: "expected 2 to 3 positional params, got 1",
+		},
+		{
+			name:          "Too many positional params",
+			method:        "add",
+			params:        `[1,2,3,4]`,
+			wantErrSubstr: "expected 2 to 3 positional params, got 4",
+		},
+		{
+			name:          "Positional type mismatch",
+			method:        "add",
+			params:        `[1,"two"]`,
+			wantErrSubstr: "invalid param at position 1",
+		},
+		{
+			name:          "Non contiguous positions",
+			method:        "invalid",
+			params:        `[1,2]`,
+			wantErrSubstr: "not contiguous",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+				Items: []UnionRequest{{
+					JSONRPC: JSONRPCVersion,
+					Method:  stringToPointer(tc.method),
+					Params:  json.RawMessage(tc.params),
+					ID:      &RequestID{Value: 1},
+				}},
+			}})
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			item := resp.Body.Items[0]
+			if tc.wantErrSubstr != "" {
+				if item.Error == nil || item.Error.Code != InvalidParamsError {
+					t.Fatalf("Expected invalid params error, got %#v", item)
+				}
+				if !strings.Contains(item.Error.Message, tc.wantErrSubstr) {
+					t.Errorf("Expected error containing %q, got %q", tc.wantErrSubstr, item.Error.Message)
+				}
+				return
+			}
+			if item.Error != nil {
+				t.Fatalf("Expected no error, got %#v", item.Error)
+			}
+			if !jsonStringsEqual(string(item.Result), tc.wantResult) {
+				t.Errorf("Expected result %s, got %s", tc.wantResult, string(item.Result))
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package reqresp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Caller sends an encoded JSON-RPC message and returns the encoded reply.
+// The reply may be empty or "null" for notifications.
+// It is implemented by the clients of all transports, so typed clients built on Call and Notify
+// work on every transport.
+type Caller interface {
+	SendContext(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+// RequestIDGenerator is implemented by callers generating the IDs of the requests sent with Call,
+// e.g. to number the requests of each connection. Call numbers the requests sent through other
+// callers with a counter shared by the process.
+type RequestIDGenerator interface {
+	NextRequestID() RequestID
+}
+
+// CallerCodec is implemented by callers encoding the params and decoding the results of Call and
+// Notify with their own codec, instead of DefaultCodec.
+type CallerCodec interface {
+	Codec() Codec
+}
+
+// callCodec returns the codec of the params and results sent through caller.
+func callCodec(caller Caller) Codec {
+	if c, ok := caller.(CallerCodec); ok && c.Codec() != nil {
+		return c.Codec()
+	}
+	return DefaultCodec()
+}
+
+// callIDs generates the IDs of requests sent with Call through callers that are not a
+// RequestIDGenerator.
+var callIDs atomic.Int64
+
+// nextCallID returns the ID of the next request sent with Call through caller.
+func nextCallID(caller Caller) RequestID {
+	if generator, ok := caller.(RequestIDGenerator); ok {
+		return generator.NextRequestID()
+	}
+	return RequestID{Value: int(callIDs.Add(1))}
+}
+
+// Call sends a request for method with params, and decodes the result of the response into O.
+// A JSON-RPC error response is returned as a *JSONRPCError, so it can be matched with errors.Is
+// and errors.As against error codes. A reply to another request is an error.
+//
+// Example:
+//
+//	result, err := Call[AddParams, AddResult](ctx, client, "add", AddParams{A: 1, B: 2})
+//	if errors.Is(err, InvalidParamsError) {
+//	    // Handle invalid params.
+//	}
+func Call[I any, O any](ctx context.Context, caller Caller, method string, params I) (O, error) {
+	var result O
+	codec := callCodec(caller)
+	rawParams, err := encodeCallParams(codec, params)
+	if err != nil {
+		return result, err
+	}
+	id := nextCallID(caller)
+	msg, err := DefaultCodec().Marshal(Request[json.RawMessage]{
+		JSONRPC: JSONRPCVersion,
+		ID:      id,
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	reply, err := caller.SendContext(ctx, msg)
+	if err != nil {
+		return result, fmt.Errorf("call %s: %w", method, err)
+	}
+	if isEmptyReply(reply) {
+		return result, fmt.Errorf("call %s: %w", method, errEmptyReply)
+	}
+	var resp Response[json.RawMessage]
+	if err := DefaultCodec().Unmarshal(reply, &resp); err != nil {
+		return result, fmt.Errorf("call %s: invalid reply: %w", method, err)
+	}
+	if resp.ID == nil && resp.Error != nil {
+		// Errors about requests whose ID could not be read, e.g. parse errors, have no ID.
+		return result, resp.Error
+	}
+	if !resp.ID.Equal(&id) {
+		return result, fmt.Errorf("call %s: %w", method, errReplyID)
+	}
+	if resp.Error != nil {
+		return result, resp.Error
+	}
+	if err := codec.Unmarshal(resp.Result, &result); err != nil {
+		return result, fmt.Errorf("call %s: invalid result: %w", method, err)
+	}
+	return result, nil
+}
+
+// Notify sends a notification for method with params.
+// An error is returned only if the notification could not be sent, as peers do not reply to
+// notifications.
+func Notify[I any](ctx context.Context, caller Caller, method string, params I) error {
+	rawParams, err := encodeCallParams(callCodec(caller), params)
+	if err != nil {
+		return err
+	}
+	msg, err := DefaultCodec().Marshal(Notification[json.RawMessage]{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := caller.SendContext(ctx, msg); err != nil {
+		return fmt.Errorf("notify %s: %w", method, err)
+	}
+	return nil
+}
+
+// encodeCallParams encodes params, returning nil for nil params so they are omitted.
+func encodeCallParams(codec Codec, params any) (json.RawMessage, error) {
+	data, err := codec.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("encode params: %w", err)
+	}
+	if isEmptyReply(data) {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// errEmptyReply is returned by Call when the peer does not reply to a request.
+var errEmptyReply = errors.New("empty reply")
+
+// errReplyID is returned by Call when the reply is the response to another request.
+var errReplyID = errors.New("reply ID does not match the request ID")
+
+// isEmptyReply reports whether data is empty or a JSON null.
+func isEmptyReply(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) == 0 || bytes.Equal(data, []byte("null"))
+}
@@ -0,0 +1,147 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// handlerCaller is a Caller dispatching messages to a handler through a Peer.
+type handlerCaller struct {
+	peer *Peer
+}
+
+func (c *handlerCaller) SendContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return c.peer.HandleMessage(ctx, msg)
+}
+
+// callerFunc is a Caller calling the function.
+type callerFunc func(ctx context.Context, msg []byte) ([]byte, error)
+
+func (f callerFunc) SendContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return f(ctx, msg)
+}
+
+func TestCallAndNotify(t *testing.T) {
+	notified := make(chan NotifyParams, 1)
+	handler := NewBatchRequestHandler(
+		WithMethodMap(map[string]IMethodHandler{
+			"add":    &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+			"concat": &MethodHandler[ConcatParams, string]{Endpoint: ConcatEndpoint},
+		}),
+		WithNotificationMap(map[string]INotificationHandler{
+			"notify": &NotificationHandler[NotifyParams]{
+				Endpoint: func(ctx context.Context, params NotifyParams) error {
+					notified <- params
+					return nil
+				},
+			},
+		}),
+	)
+	caller := &handlerCaller{peer: NewPeer(handler, nil)}
+
+	sum, err := Call[AddParams, AddResult](t.Context(), caller, "add", AddParams{A: 2, B: 3})
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if sum.Sum != 5 {
+		t.Errorf("Expected sum 5, got %d", sum.Sum)
+	}
+
+	s, err := Call[ConcatParams, string](t.Context(), caller, "concat", ConcatParams{S1: "a", S2: "b"})
+	if err != nil || s != "ab" {
+		t.Errorf("Expected \"ab\", got %q, %v", s, err)
+	}
+
+	_, err = Call[AddParams, AddResult](t.Context(), caller, "missing", AddParams{})
+	var jerr *JSONRPCError
+	if !errors.As(err, &jerr) || jerr.Code != MethodNotFoundError {
+		t.Errorf("Expected typed method not found error, got %v", err)
+	}
+	_, err = Call[string, AddResult](t.Context(), caller, "add", "not an object")
+	if !errors.Is(err, InvalidParamsError) {
+		t.Errorf("Expected invalid params error, got %v", err)
+	}
+
+	if err := Notify(t.Context(), caller, "notify", NotifyParams{Message: "hi"}); err != nil {
+		t.Fatalf("Notify returned error: %v", err)
+	}
+	if got := <-notified; got.Message != "hi" {
+		t.Errorf("Expected notification message %q, got %q", "hi", got.Message)
+	}
+
+	// A request without a reply fails, instead of returning a zero result.
+	noReply := callerFunc(func(ctx context.Context, msg []byte) ([]byte, error) {
+		return []byte("null"), nil
+	})
+	_, err = Call[AddParams, AddResult](t.Context(), noReply, "add", AddParams{})
+	if !errors.Is(err, errEmptyReply) {
+		t.Errorf("Expected empty reply error, got %v", err)
+	}
+
+	// A reply to another request fails.
+	for _, reply := range []string{
+		`{"jsonrpc": "2.0", "id": -1, "result": {"sum": 0}}`,
+		`{"jsonrpc": "2.0", "result": {"sum": 0}}`,
+	} {
+		wrongID := callerFunc(func(ctx context.Context, msg []byte) ([]byte, error) {
+			return []byte(reply), nil
+		})
+		if _, err := Call[AddParams, AddResult](t.Context(), wrongID, "add", AddParams{}); !errors.Is(err, errReplyID) {
+			t.Errorf("Expected reply ID error for %s, got %v", reply, err)
+		}
+	}
+	parseError := callerFunc(func(ctx context.Context, msg []byte) ([]byte, error) {
+		return []byte(`{"jsonrpc": "2.0", "id": null, "error": {"code": -32700, "message": "Parse error"}}`), nil
+	})
+	if _, err := Call[AddParams, AddResult](t.Context(), parseError, "add", AddParams{}); !errors.Is(err, ParseError) {
+		t.Errorf("Expected parse error without ID, got %v", err)
+	}
+
+	sendErr := errors.New("connection reset")
+	failing := callerFunc(func(ctx context.Context, msg []byte) ([]byte, error) {
+		return nil, sendErr
+	})
+	if err := Notify(t.Context(), failing, "notify", NotifyParams{}); !errors.Is(err, sendErr) {
+		t.Errorf("Expected send error to be wrapped, got %v", err)
+	}
+}
+
+// idCaller is a Caller generating the IDs of its requests.
+type idCaller struct {
+	handlerCaller
+	ids []RequestID
+}
+
+func (c *idCaller) NextRequestID() RequestID {
+	id := RequestID{Value: "req-" + strconv.Itoa(len(c.ids))}
+	c.ids = append(c.ids, id)
+	return id
+}
+
+func (c *idCaller) SendContext(ctx context.Context, msg []byte) ([]byte, error) {
+	var req Request[json.RawMessage]
+	if err := json.Unmarshal(msg, &req); err != nil || !req.ID.Equal(&c.ids[len(c.ids)-1]) {
+		return nil, fmt.Errorf("unexpected request %s", msg)
+	}
+	return c.handlerCaller.SendContext(ctx, msg)
+}
+
+func TestCallerRequestIDs(t *testing.T) {
+	handler := NewBatchRequestHandler(WithMethodMap(map[string]IMethodHandler{
+		"add": &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+	}))
+	caller := &idCaller{handlerCaller: handlerCaller{peer: NewPeer(handler, nil)}}
+	for range 2 {
+		if sum, err := Call[AddParams, AddResult](t.Context(), caller, "add", AddParams{A: 1, B: 2}); err != nil ||
+			sum.Sum != 3 {
+			t.Fatalf("Expected 3, got %d, %v", sum.Sum, err)
+		}
+	}
+	if len(caller.ids) != 2 {
+		t.Errorf("Expected the caller to generate 2 IDs, got %v", caller.ids)
+	}
+}
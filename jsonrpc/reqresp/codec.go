@@ -0,0 +1,132 @@
+package reqresp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Codec encodes and decodes JSON values.
+//
+// The codec of a BatchRequestHandler, set with WithCodec, decodes the params and encodes the results
+// of its handlers, and those of the calls made by a Peer serving with it. Callers implementing
+// CallerCodec set the codec of the params and results of Call and Notify. DefaultCodec is used
+// otherwise, and always for the JSON-RPC envelope, i.e. BatchItem, IntString and BatchResponse,
+// which implement json.Marshaler and json.Unmarshaler so that any JSON encoder can write them.
+//
+// The Huma transports decode request bodies and encode response bodies with the formats of the huma
+// API, see huma.Config.Formats, not with a Codec. Params reach the handlers as raw JSON, and the
+// results encoded by the handler codec are embedded as raw JSON, which the JSON format of huma
+// re-encodes, i.e. validates and compacts.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// StdJSONCodec is a Codec using encoding/json.
+type StdJSONCodec struct{}
+
+// Marshal implements Codec.
+func (StdJSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (StdJSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// StrictJSONCodec is a Codec using encoding/json, that rejects objects with duplicate keys and
+// properties not matching a field of the target struct.
+type StrictJSONCodec struct{}
+
+// Marshal implements Codec.
+func (StrictJSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (StrictJSONCodec) Unmarshal(data []byte, v any) error {
+	if err := checkDuplicateKeys(data); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// checkDuplicateKeys returns an error if an object within data has the same key more than once.
+func checkDuplicateKeys(data []byte) error {
+	return checkValueKeys(json.NewDecoder(bytes.NewReader(data)))
+}
+
+// checkValueKeys consumes the next value of decoder, checking the keys of all objects within it.
+func checkValueKeys(decoder *json.Decoder) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		keys := make(map[string]struct{})
+		for decoder.More() {
+			keyTok, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+			if _, exists := keys[key]; exists {
+				return fmt.Errorf("duplicate key %q", key)
+			}
+			keys[key] = struct{}{}
+			if err := checkValueKeys(decoder); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for decoder.More() {
+			if err := checkValueKeys(decoder); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+	// Consume the closing delimiter.
+	_, err = decoder.Token()
+	return err
+}
+
+// DefaultCodec returns the codec used when no other is set, which is StdJSONCodec.
+func DefaultCodec() Codec {
+	return StdJSONCodec{}
+}
+
+// WithCodec sets the codec used for the params and results of the handlers
+// dispatched to by this handler. The default is DefaultCodec.
+func WithCodec(codec Codec) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.codec = codec
+	}
+}
+
+func contextWithCodec(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, ctxKeyCodec, codec)
+}
+
+// GetCodec returns the codec of the handler processing the current message, or DefaultCodec.
+func GetCodec(ctx context.Context) Codec {
+	if codec, ok := ctx.Value(ctxKeyCodec).(Codec); ok {
+		return codec
+	}
+	return DefaultCodec()
+}
@@ -0,0 +1,127 @@
+package reqresp
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// countingCodec counts the calls to the wrapped codec.
+type countingCodec struct {
+	Codec
+	marshals   atomic.Int64
+	unmarshals atomic.Int64
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshals.Add(1)
+	return c.Codec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshals.Add(1)
+	return c.Codec.Unmarshal(data, v)
+}
+
+func TestStrictJSONCodec(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		wantErrSubstr string
+	}{
+		{name: "Valid", data: `{"a":1,"b":2}`},
+		{name: "Duplicate key", data: `{"a":1,"a":2}`, wantErrSubstr: `duplicate key "a"`},
+		{name: "Nested duplicate key", data: `{"a":1,"b":[{"x":1,"x":2}]}`, wantErrSubstr: `duplicate key "x"`},
+		{name: "Unknown field", data: `{"a":1,"b":2,"c":3}`, wantErrSubstr: `unknown field "c"`},
+		{name: "Trailing data", data: `{"a":1,"b":2} {}`, wantErrSubstr: "after top-level value"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var params AddParams
+			err := StrictJSONCodec{}.Unmarshal([]byte(tc.data), &params)
+			if tc.wantErrSubstr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Errorf("Expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
+
+func TestHandlerCodec(t *testing.T) {
+	codec := &countingCodec{Codec: StrictJSONCodec{}}
+	brh := NewBatchRequestHandler(
+		WithMethodMap(map[string]IMethodHandler{
+			"add": &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+		}),
+		WithCodec(codec),
+	)
+	resp, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+		IsBatch: true,
+		Items: []UnionRequest{
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("add"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+				ID:      &RequestID{Value: 1},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("add"),
+				Params:  json.RawMessage(`{"a":1,"b":2,"c":3}`),
+				ID:      &RequestID{Value: 2},
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if item := resp.Body.Items[0]; item.Error != nil || !jsonStringsEqual(string(item.Result), `{"sum":3}`) {
+		t.Errorf("Expected valid params to be accepted, got %#v", item)
+	}
+	if item := resp.Body.Items[1]; item.Error == nil || item.Error.Code != InvalidParamsError {
+		t.Errorf("Expected unknown field to be rejected by the handler codec, got %#v", item)
+	}
+	if codec.unmarshals.Load() != 2 || codec.marshals.Load() != 1 {
+		t.Errorf("Expected 2 unmarshals and 1 marshal, got %d and %d",
+			codec.unmarshals.Load(), codec.marshals.Load())
+	}
+}
+
+// codecCaller is a handlerCaller with its own codec.
+type codecCaller struct {
+	handlerCaller
+	codec Codec
+}
+
+func (c *codecCaller) Codec() Codec {
+	return c.codec
+}
+
+func TestCallerCodec(t *testing.T) {
+	handler := NewBatchRequestHandler(WithMethodMap(map[string]IMethodHandler{
+		"add": &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+	}))
+	codec := &countingCodec{Codec: StdJSONCodec{}}
+	caller := &codecCaller{handlerCaller: handlerCaller{peer: NewPeer(handler, nil)}, codec: codec}
+
+	sum, err := Call[AddParams, AddResult](t.Context(), caller, "add", AddParams{A: 2, B: 3})
+	if err != nil || sum.Sum != 5 {
+		t.Fatalf("Expected sum 5, got %d, %v", sum.Sum, err)
+	}
+	if codec.marshals.Load() != 1 || codec.unmarshals.Load() != 1 {
+		t.Errorf("Expected the caller codec to encode params and decode the result, got %d and %d",
+			codec.marshals.Load(), codec.unmarshals.Load())
+	}
+
+	caller.codec = StrictJSONCodec{}
+	_, err = Call[AddParams, struct{}](t.Context(), caller, "add", AddParams{A: 2, B: 3})
+	if err == nil || !strings.Contains(err.Error(), `unknown field "sum"`) {
+		t.Errorf("Expected the result to be decoded by the caller codec, got %v", err)
+	}
+}
@@ -1,5 +1,13 @@
 package reqresp
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
 type JSONRPCErrorCode int
 
 const (
@@ -28,10 +36,159 @@ var errorMessage = map[JSONRPCErrorCode]string{
 	InternalError:       "Internal JSON-RPC error",
 }
 
+// Bounds of the error codes reserved by the JSON-RPC specification.
+// Of these, MinServerErrorCode to MaxServerErrorCode are free for implementation defined server errors.
+const (
+	minReservedErrorCode JSONRPCErrorCode = -32768
+	maxReservedErrorCode JSONRPCErrorCode = -32000
+	MinServerErrorCode   JSONRPCErrorCode = -32099
+	MaxServerErrorCode   JSONRPCErrorCode = -32000
+)
+
+var errorMessageMu sync.RWMutex
+
+type errorMapping struct {
+	target error
+	code   JSONRPCErrorCode
+}
+
+// GetDefaultErrorMessage returns the message of a standard or registered error code.
 func GetDefaultErrorMessage(code JSONRPCErrorCode) string {
+	errorMessageMu.RLock()
+	defer errorMessageMu.RUnlock()
 	return errorMessage[code]
 }
 
+// RegisterErrorCode declares an application error code with its default message.
+// Codes reserved by the specification are rejected, except for the server error range
+// MinServerErrorCode to MaxServerErrorCode. Codes can be registered only once.
+//
+// Example:
+//
+//	const ErrCodeNotFound reqresp.JSONRPCErrorCode = -32002
+//
+//	func init() {
+//	    if err := reqresp.RegisterErrorCode(ErrCodeNotFound, "Resource not found"); err != nil {
+//	        panic(err)
+//	    }
+//	}
+func RegisterErrorCode(code JSONRPCErrorCode, message string) error {
+	if isReservedErrorCode(code) {
+		return fmt.Errorf("error code %d is reserved by the JSON-RPC specification", code)
+	}
+	errorMessageMu.Lock()
+	defer errorMessageMu.Unlock()
+	if _, exists := errorMessage[code]; exists {
+		return fmt.Errorf("error code %d is already registered", code)
+	}
+	errorMessage[code] = message
+	return nil
+}
+
+// UnregisterErrorCode removes an application error code registered with RegisterErrorCode, e.g. in the
+// cleanup of a test. Codes reserved by the specification are kept.
+func UnregisterErrorCode(code JSONRPCErrorCode) {
+	if isReservedErrorCode(code) {
+		return
+	}
+	errorMessageMu.Lock()
+	defer errorMessageMu.Unlock()
+	delete(errorMessage, code)
+}
+
+// isReservedErrorCode reports whether code is reserved by the specification, outside of the server
+// error range.
+func isReservedErrorCode(code JSONRPCErrorCode) bool {
+	return code >= minReservedErrorCode && code <= maxReservedErrorCode &&
+		(code < MinServerErrorCode || code > MaxServerErrorCode)
+}
+
+// WithErrorMapping maps the errors matching target, as per errors.Is, to code. Errors returned by the
+// method endpoints of the handler that are not a JSONRPCError are converted using these mappings, in
+// the order of the options, before falling back to InternalError.
+func WithErrorMapping(target error, code JSONRPCErrorCode) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.errorMappings = append(h.errorMappings, errorMapping{target: target, code: code})
+	}
+}
+
+func contextWithErrorMappings(ctx context.Context, mappings []errorMapping) context.Context {
+	return context.WithValue(ctx, ctxKeyErrorMappings, mappings)
+}
+
+// mapError returns the JSONRPCError for err.
+// It is err itself if it wraps a JSONRPCError, or the error of the first mapping of the handler
+// processing the current message matching err.
+func mapError(ctx context.Context, err error) (*JSONRPCError, bool) {
+	var jsonrpcErr *JSONRPCError
+	if errors.As(err, &jsonrpcErr) {
+		return jsonrpcErr, true
+	}
+	var jsonrpcErrValue JSONRPCError
+	if errors.As(err, &jsonrpcErrValue) {
+		return &jsonrpcErrValue, true
+	}
+	mappings, _ := ctx.Value(ctxKeyErrorMappings).([]errorMapping)
+	for _, m := range mappings {
+		if errors.Is(err, m.target) {
+			return WrapError(m.code, err), true
+		}
+	}
+	return nil, false
+}
+
+// Error implements error, so that codes can be used as targets of errors.Is.
+//
+// Example:
+//
+//	if errors.Is(err, reqresp.InvalidParamsError) {
+//	    // Handle invalid params.
+//	}
+func (c JSONRPCErrorCode) Error() string {
+	if msg := GetDefaultErrorMessage(c); msg != "" {
+		return msg
+	}
+	return "JSON-RPC error " + strconv.Itoa(int(c))
+}
+
+// NewError returns an error with the default message of code and the given data.
+func NewError(code JSONRPCErrorCode, data any) *JSONRPCError {
+	return &JSONRPCError{Code: code, Message: GetDefaultErrorMessage(code), Data: data}
+}
+
+// WrapError returns an error with code, whose message is the default message of code followed
+// by the message of cause. The cause is available through errors.Unwrap, but not sent to the peer.
+func WrapError(code JSONRPCErrorCode, cause error) *JSONRPCError {
+	msg := cause.Error()
+	if defaultMsg := GetDefaultErrorMessage(code); defaultMsg != "" {
+		msg = defaultMsg + ": " + msg
+	}
+	return &JSONRPCError{Code: code, Message: msg, cause: cause}
+}
+
+// ErrorData decodes the Data of the JSONRPCError in err's chain into T.
+// This works both for errors created locally, and for errors received from a peer,
+// whose Data is a generic JSON value.
+func ErrorData[T any](err error) (T, error) {
+	var data T
+	var jsonrpcErr *JSONRPCError
+	if !errors.As(err, &jsonrpcErr) {
+		return data, errors.New("not a JSON-RPC error")
+	}
+	if d, ok := jsonrpcErr.Data.(T); ok {
+		return d, nil
+	}
+	if jsonrpcErr.Data == nil {
+		return data, errors.New("JSON-RPC error has no data")
+	}
+	raw, err := DefaultCodec().Marshal(jsonrpcErr.Data)
+	if err != nil {
+		return data, err
+	}
+	err = DefaultCodec().Unmarshal(raw, &data)
+	return data, err
+}
+
 // Error defines a JSON RPC error that can be returned in a Response from the spec
 // http://www.jsonrpc.org/specification#error_object
 type JSONRPCError struct {
@@ -45,6 +202,9 @@ type JSONRPCError struct {
 	// Additional information about the error. The value of this member is defined by
 	// the sender (e.g. detailed error information, nested errors etc.).
 	Data any `json:"data,omitempty"`
+
+	// Local cause of the error, not sent to the peer.
+	cause error
 }
 
 // Error implements error.
@@ -52,10 +212,28 @@ func (e JSONRPCError) Error() string {
 	if e.Message != "" {
 		return e.Message
 	}
-	return errorMessage[e.Code]
+	return GetDefaultErrorMessage(e.Code)
 }
 
 // ErrorCode returns the JSON RPC error code associated with the error.
 func (e JSONRPCError) ErrorCode() JSONRPCErrorCode {
 	return e.Code
 }
+
+// Unwrap returns the cause given to WrapError.
+func (e JSONRPCError) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is the code of e, or a JSONRPCError with the same code.
+func (e JSONRPCError) Is(target error) bool {
+	switch t := target.(type) {
+	case JSONRPCErrorCode:
+		return e.Code == t
+	case *JSONRPCError:
+		return t != nil && e.Code == t.Code
+	case JSONRPCError:
+		return e.Code == t.Code
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const (
+	errCodeNotFound JSONRPCErrorCode = -32002
+	errCodeQuota    JSONRPCErrorCode = 4001
+)
+
+var errTestNotFound = errors.New("not found")
+
+type quotaData struct {
+	Limit int `json:"limit"`
+}
+
+// registerTestErrorCodes registers the error codes of the tests until the end of t.
+func registerTestErrorCodes(t *testing.T) {
+	t.Helper()
+	for code, msg := range map[JSONRPCErrorCode]string{
+		errCodeNotFound: "Resource not found",
+		errCodeQuota:    "Quota exceeded",
+	} {
+		if err := RegisterErrorCode(code, msg); err != nil {
+			t.Fatalf("RegisterErrorCode returned error: %v", err)
+		}
+		t.Cleanup(func() { UnregisterErrorCode(code) })
+	}
+}
+
+func TestRegisterErrorCode(t *testing.T) {
+	registerTestErrorCodes(t)
+	tests := []struct {
+		name    string
+		code    JSONRPCErrorCode
+		wantErr bool
+	}{
+		{name: "Reserved code", code: -32500, wantErr: true},
+		{name: "Standard code", code: InvalidParamsError, wantErr: true},
+		{name: "Already registered", code: errCodeNotFound, wantErr: true},
+		{name: "Server error range", code: MinServerErrorCode},
+		{name: "Application code", code: 5001},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := RegisterErrorCode(tc.code, "Test error")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error: %v, got %v", tc.wantErr, err)
+			}
+			if err == nil {
+				t.Cleanup(func() { UnregisterErrorCode(tc.code) })
+			}
+			if !tc.wantErr && GetDefaultErrorMessage(tc.code) != "Test error" {
+				t.Errorf("Expected registered message, got %q", GetDefaultErrorMessage(tc.code))
+			}
+		})
+	}
+
+	// Unregistered codes can be registered again, standard codes cannot be unregistered.
+	UnregisterErrorCode(errCodeNotFound)
+	if GetDefaultErrorMessage(errCodeNotFound) != "" {
+		t.Errorf("Expected no message for an unregistered code")
+	}
+	if err := RegisterErrorCode(errCodeNotFound, "Resource not found"); err != nil {
+		t.Errorf("Expected the unregistered code to be registered again, got %v", err)
+	}
+	UnregisterErrorCode(InvalidParamsError)
+	if GetDefaultErrorMessage(InvalidParamsError) == "" {
+		t.Errorf("Expected the standard code to be kept")
+	}
+}
+
+func TestErrorMessageConcurrentRegister(t *testing.T) {
+	registerTestErrorCodes(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			code := JSONRPCErrorCode(6000 + i)
+			if RegisterErrorCode(code, "Concurrent error") == nil {
+				t.Cleanup(func() { UnregisterErrorCode(code) })
+			}
+		}
+	}()
+	for range 100 {
+		if msg := (JSONRPCError{Code: errCodeQuota}).Error(); msg != "Quota exceeded" {
+			t.Fatalf("Expected default message, got %q", msg)
+		}
+	}
+	<-done
+}
+
+func TestErrorMatching(t *testing.T) {
+	registerTestErrorCodes(t)
+	cause := fmt.Errorf("lookup item 7: %w", errTestNotFound)
+	wrapped := fmt.Errorf("handler: %w", WrapError(errCodeNotFound, cause))
+
+	if !errors.Is(wrapped, errCodeNotFound) {
+		t.Errorf("Expected wrapped error to match its code")
+	}
+	if !errors.Is(wrapped, &JSONRPCError{Code: errCodeNotFound}) {
+		t.Errorf("Expected wrapped error to match an error with the same code")
+	}
+	if errors.Is(wrapped, errCodeQuota) {
+		t.Errorf("Expected wrapped error not to match another code")
+	}
+	if !errors.Is(wrapped, errTestNotFound) {
+		t.Errorf("Expected wrapped error to match its cause")
+	}
+	want := "Resource not found: lookup item 7: not found"
+	if got := WrapError(errCodeNotFound, cause).Message; got != want {
+		t.Errorf("Expected message %q, got %q", want, got)
+	}
+
+	// Data received from a peer is a generic JSON value.
+	var received JSONRPCError
+	raw := `{"code":4001,"message":"Quota exceeded","data":{"limit":10}}`
+	if err := json.Unmarshal([]byte(raw), &received); err != nil {
+		t.Fatalf("Could not unmarshal error: %v", err)
+	}
+	for _, err := range []error{&received, NewError(errCodeQuota, quotaData{Limit: 10})} {
+		if !errors.Is(err, errCodeQuota) {
+			t.Errorf("Expected %v to match its code", err)
+		}
+		data, dataErr := ErrorData[quotaData](err)
+		if dataErr != nil || data.Limit != 10 {
+			t.Errorf("Expected decoded data with limit 10, got %#v, %v", data, dataErr)
+		}
+	}
+}
+
+func TestMethodErrorMapping(t *testing.T) {
+	registerTestErrorCodes(t)
+	methodMap := map[string]IMethodHandler{
+		"get": &MethodHandler[struct{}, struct{}]{
+			Endpoint: func(ctx context.Context, _ struct{}) (struct{}, error) {
+				return struct{}{}, fmt.Errorf("get: %w", errTestNotFound)
+			},
+		},
+		"quota": &MethodHandler[struct{}, struct{}]{
+			Endpoint: func(ctx context.Context, _ struct{}) (struct{}, error) {
+				return struct{}{}, fmt.Errorf("quota: %w", NewError(errCodeQuota, quotaData{Limit: 10}))
+			},
+		},
+		"value": &MethodHandler[struct{}, struct{}]{
+			Endpoint: func(ctx context.Context, _ struct{}) (struct{}, error) {
+				return struct{}{}, JSONRPCError{Code: errCodeQuota, Message: "Quota exceeded"}
+			},
+		},
+	}
+	mapped := NewBatchRequestHandler(WithMethodMap(methodMap), WithErrorMapping(errTestNotFound, errCodeNotFound))
+	// Mappings only apply to the handler they are given to.
+	unmapped := NewBatchRequestHandler(WithMethodMap(methodMap))
+
+	tests := []struct {
+		brh         *BatchRequestHandler
+		method      string
+		wantCode    JSONRPCErrorCode
+		wantMessage string
+		wantData    any
+	}{
+		{brh: mapped, method: "get", wantCode: errCodeNotFound, wantMessage: "Resource not found: get: not found"},
+		{brh: unmapped, method: "get", wantCode: InternalError, wantMessage: "Internal JSON-RPC error: get: not found"},
+		{brh: mapped, method: "quota", wantCode: errCodeQuota, wantMessage: "Quota exceeded", wantData: quotaData{Limit: 10}},
+		{brh: unmapped, method: "value", wantCode: errCodeQuota, wantMessage: "Quota exceeded"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method, func(t *testing.T) {
+			resp, err := tc.brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+				Items: []UnionRequest{{
+					JSONRPC: JSONRPCVersion,
+					Method:  stringToPointer(tc.method),
+					ID:      &RequestID{Value: 1},
+				}},
+			}})
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			got := resp.Body.Items[0].Error
+			if got == nil || got.Code != tc.wantCode || got.Message != tc.wantMessage || got.Data != tc.wantData {
+				t.Errorf("Unexpected error. Got: %#v, Want code %d, message %q and data %#v",
+					got, tc.wantCode, tc.wantMessage, tc.wantData)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"sync"
 )
 
 // Now, we can define BatchRequest and BatchResponse using BatchItem[T].
@@ -19,7 +21,7 @@ func (b BatchResponse) MarshalJSON() ([]byte, error) {
 	if b.Body == nil {
 		return []byte("null"), nil
 	}
-	return json.Marshal(struct {
+	return DefaultCodec().Marshal(struct {
 		Body *BatchItem[Response[json.RawMessage]] `json:"Body"`
 	}{
 		Body: b.Body,
@@ -27,26 +29,74 @@ func (b BatchResponse) MarshalJSON() ([]byte, error) {
 }
 
 type BatchRequestHandler struct {
-	methodMap             map[string]IMethodHandler
-	notificationMap       map[string]INotificationHandler
-	responseMap           map[string]IResponseHandler
+	registry *Registry
+	// Router consulted before the registry, if set.
+	router                *Router
 	responseHandlerMapper func(context.Context, Response[json.RawMessage]) (string, error)
+
+	// Handlers given via the map options, added to the registry on construction.
+	methodMap       map[string]IMethodHandler
+	notificationMap map[string]INotificationHandler
+	responseMap     map[string]IResponseHandler
+
+	// Number of items of a single batch that may be processed at the same time.
+	// Values <= 1 process the batch sequentially.
+	batchConcurrency int
+	// Semaphore bounding the items in flight across all batches handled by this handler.
+	// Nil means no global cap.
+	inFlight chan struct{}
+
+	batchMiddlewares        []BatchMiddleware
+	methodMiddlewares       []MethodMiddleware
+	notificationMiddlewares []NotificationMiddleware
+	responseMiddlewares     []ResponseMiddleware
+
+	// Panics during dispatch are always recovered, these control how they are reported.
+	panicStack bool
+	panicHook  func(context.Context, PanicInfo)
+
+	observers []Observer
+
+	// Codec for params and results, nil to use DefaultCodec.
+	codec Codec
+	// Mappings of the errors of method endpoints to error codes, see WithErrorMapping.
+	errorMappings []errorMapping
 }
 
 type HandlerOption func(*BatchRequestHandler)
 
+// WithRegistry makes the handler dispatch to the handlers in registry.
+// Handlers can be added to or removed from the registry while serving.
+func WithRegistry(registry *Registry) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.registry = registry
+	}
+}
+
+// WithRouter makes the handler dispatch to the registries mounted in router.
+// Names the router does not resolve are looked up in the handler's registry.
+func WithRouter(router *Router) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.router = router
+	}
+}
+
+// WithMethodMap registers the method handlers in the handler's registry.
 func WithMethodMap(methodMap map[string]IMethodHandler) HandlerOption {
 	return func(h *BatchRequestHandler) {
 		h.methodMap = methodMap
 	}
 }
 
+// WithNotificationMap registers the notification handlers in the handler's registry.
 func WithNotificationMap(notificationMap map[string]INotificationHandler) HandlerOption {
 	return func(h *BatchRequestHandler) {
 		h.notificationMap = notificationMap
 	}
 }
 
+// WithResponseMap registers the response handlers in the handler's registry.
+// The mapper returns the method name, whose response handler should process a response.
 func WithResponseMap(
 	responseMap map[string]IResponseHandler,
 	mapper func(context.Context, Response[json.RawMessage]) (string, error),
@@ -57,13 +107,30 @@ func WithResponseMap(
 	}
 }
 
+// WithBatchConcurrency processes up to maxPerBatch items of a batch concurrently.
+// Responses are still returned in request order. Values <= 1 keep sequential processing.
+func WithBatchConcurrency(maxPerBatch int) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.batchConcurrency = maxPerBatch
+	}
+}
+
+// WithMaxInFlight caps the number of items being processed at the same time across all
+// batches handled by this handler. Values <= 0 remove the cap.
+func WithMaxInFlight(maxInFlight int) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		if maxInFlight <= 0 {
+			h.inFlight = nil
+			return
+		}
+		h.inFlight = make(chan struct{}, maxInFlight)
+	}
+}
+
 func NewBatchRequestHandler(
 	opts ...HandlerOption,
 ) *BatchRequestHandler {
 	handler := &BatchRequestHandler{
-		methodMap:       make(map[string]IMethodHandler),
-		notificationMap: make(map[string]INotificationHandler),
-		responseMap:     make(map[string]IResponseHandler),
 		// Or provide a default mapper if applicable.
 		responseHandlerMapper: nil,
 	}
@@ -72,12 +139,41 @@ func NewBatchRequestHandler(
 		opt(handler)
 	}
 
+	if handler.registry == nil {
+		handler.registry = NewRegistry()
+	}
+	for name, h := range handler.methodMap {
+		handler.registry.RegisterMethod(name, h)
+	}
+	for name, h := range handler.notificationMap {
+		handler.registry.RegisterNotification(name, h)
+	}
+	for name, h := range handler.responseMap {
+		handler.registry.RegisterResponse(name, h)
+	}
+
 	return handler
 }
 
+// Registry returns the registry the handler dispatches to.
+func (brh *BatchRequestHandler) Registry() *Registry {
+	return brh.registry
+}
+
+// Handle processes a single request or a batch, and returns the responses to send back.
 func (brh *BatchRequestHandler) Handle(
 	ctx context.Context,
 	metaReq *BatchRequest,
+) (*BatchResponse, error) {
+	if len(brh.batchMiddlewares) == 0 {
+		return brh.handle(ctx, metaReq)
+	}
+	return chainBatch(brh.handle, brh.batchMiddlewares)(ctx, metaReq)
+}
+
+func (brh *BatchRequestHandler) handle(
+	ctx context.Context,
+	metaReq *BatchRequest,
 ) (*BatchResponse, error) {
 	if metaReq == nil || metaReq.Body == nil || len(metaReq.Body.Items) == 0 {
 		item := Response[json.RawMessage]{
@@ -98,6 +194,12 @@ func (brh *BatchRequestHandler) Handle(
 		return &ret, nil
 	}
 
+	if brh.codec != nil {
+		ctx = contextWithCodec(ctx, brh.codec)
+	}
+	if len(brh.errorMappings) > 0 {
+		ctx = contextWithErrorMappings(ctx, brh.errorMappings)
+	}
 	resp := BatchResponse{
 		Body: &BatchItem[Response[json.RawMessage]]{
 			IsBatch: metaReq.Body.IsBatch,
@@ -105,45 +207,203 @@ func (brh *BatchRequestHandler) Handle(
 		},
 	}
 
-	for _, request := range metaReq.Body.Items {
-		msgType, jerr := brh.detectMessageType(request)
-		if jerr != nil {
-			resp.Body.Items = append(resp.Body.Items, Response[json.RawMessage]{
+	// Each slot holds the response for the item at the same position, nil if it produces none.
+	results := make([]*Response[json.RawMessage], len(metaReq.Body.Items))
+	brh.processItems(ctx, metaReq.Body.Items, results)
+	for _, response := range results {
+		if response != nil {
+			resp.Body.Items = append(resp.Body.Items, *response)
+		}
+	}
+
+	// If there are no responses to return, return empty response.
+	if len(resp.Body.Items) == 0 {
+		return &BatchResponse{}, nil
+	}
+	// Log.Printf("%#v", resp.Body).
+	return &resp, nil
+}
+
+// processItems handles all items of a batch and stores the responses at the position of their request.
+func (brh *BatchRequestHandler) processItems(
+	ctx context.Context,
+	items []UnionRequest,
+	results []*Response[json.RawMessage],
+) {
+	workers := min(brh.batchConcurrency, len(items))
+	if workers <= 1 {
+		for i, request := range items {
+			results[i] = brh.handleItem(ctx, request)
+		}
+		return
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = brh.handleItem(ctx, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// handleItem processes a single item of a batch.
+// It returns nil if the item does not produce a response.
+// A panic in a handler is recovered and only fails the item that caused it.
+func (brh *BatchRequestHandler) handleItem(
+	ctx context.Context,
+	request UnionRequest,
+) (response *Response[json.RawMessage]) {
+	msgType, jerr := brh.detectMessageType(request)
+	if jerr != nil {
+		return &Response[json.RawMessage]{
+			JSONRPC: JSONRPCVersion,
+			ID:      request.ID,
+			Error:   jerr,
+		}
+	}
+	defer brh.recoverItem(ctx, request, msgType, &response)
+
+	if brh.inFlight != nil {
+		select {
+		case brh.inFlight <- struct{}{}:
+			defer func() { <-brh.inFlight }()
+		case <-ctx.Done():
+			if msgType != MessageTypeMethod {
+				brh.observe(ctx, newEvent(EventMessageSkipped, msgType, request, ctx.Err()))
+				return nil
+			}
+			return &Response[json.RawMessage]{
 				JSONRPC: JSONRPCVersion,
 				ID:      request.ID,
-				Error:   jerr,
-			})
-			continue
+				Error: &JSONRPCError{
+					Code:    InternalError,
+					Message: GetDefaultErrorMessage(InternalError) + ": " + ctx.Err().Error(),
+				},
+			}
+		}
+	}
+
+	switch msgType {
+	case MessageTypeNotification:
+		handled, err := handleNotification(
+			ctx,
+			request,
+			brh.LookupNotification,
+			brh.notificationMiddlewares,
+		)
+		// Even if notification was not found or failed, you cannot send anything back.
+		switch {
+		case !handled:
+			brh.observe(ctx, newEvent(EventNotificationNotFound, msgType, request, err))
+		case err != nil:
+			brh.observe(ctx, newEvent(EventNotificationFailed, msgType, request, err))
 		}
+		return nil
 
+	case MessageTypeMethod:
+		response := handleMethod(ctx, request, brh.LookupMethod, brh.methodMiddlewares)
+		return &response
+
+	case MessageTypeResponse:
+		if brh.responseHandlerMapper == nil {
+			brh.observe(ctx, newEvent(EventResponseUnmatched, msgType, request, errNoResponseMapper))
+			return nil
+		}
+		method, handled, err := handleResponse(
+			ctx,
+			request,
+			brh.lookupResponse,
+			brh.responseHandlerMapper,
+			brh.responseMiddlewares,
+		)
+		event := newEvent(EventResponseFailed, msgType, request, err)
+		event.Method = method
 		switch {
-		case msgType == MessageTypeNotification && brh.notificationMap != nil:
-			_ = handleNotification(ctx, request, brh.notificationMap)
-			// Cannot return error; possibly log internally
-			// Even if notification was not found, you cannot send anything back.
-			continue
+		case !handled:
+			event.Kind = EventResponseUnmatched
+			brh.observe(ctx, event)
+		case err != nil:
+			brh.observe(ctx, event)
+		}
+		return nil
 
-		case msgType == MessageTypeMethod && brh.methodMap != nil:
-			response := handleMethod(ctx, request, brh.methodMap)
-			resp.Body.Items = append(resp.Body.Items, response)
-			continue
+	default:
+		brh.observe(ctx, newEvent(EventMessageSkipped, msgType, request, nil))
+		return nil
+	}
+}
 
-		case msgType == MessageTypeResponse && brh.responseMap != nil && brh.responseHandlerMapper != nil:
-			_ = handleResponse(ctx, request, brh.responseMap, brh.responseHandlerMapper)
-			continue
+// LookupMethod returns the handler serving a method, from the router or else the registry.
+func (brh *BatchRequestHandler) LookupMethod(name string) (IMethodHandler, bool) {
+	if brh.router != nil {
+		if handler, ok := brh.router.LookupMethod(name); ok {
+			return handler, true
+		}
+	}
+	return brh.registry.LookupMethod(name)
+}
 
-		default:
-			// Possibly log this.
-			continue
+// LookupNotification returns the handler serving a notification, from the router or else the registry.
+func (brh *BatchRequestHandler) LookupNotification(name string) (INotificationHandler, bool) {
+	if brh.router != nil {
+		if handler, ok := brh.router.LookupNotification(name); ok {
+			return handler, true
 		}
 	}
+	return brh.registry.LookupNotification(name)
+}
 
-	// If there are no responses to return, return empty response.
-	if len(resp.Body.Items) == 0 {
-		return &BatchResponse{}, nil
+// MethodMap returns a snapshot of the method handlers served, by method name: those of the registry
+// and those mounted in the router, which take precedence. Fallbacks of the router are not included.
+func (brh *BatchRequestHandler) MethodMap() map[string]IMethodHandler {
+	methodMap := brh.registry.MethodMap()
+	if brh.router != nil {
+		maps.Copy(methodMap, brh.router.MethodMap())
 	}
-	// Log.Printf("%#v", resp.Body).
-	return &resp, nil
+	return methodMap
+}
+
+// NotificationMap returns a snapshot of the notification handlers served, by method name, like
+// MethodMap.
+func (brh *BatchRequestHandler) NotificationMap() map[string]INotificationHandler {
+	notificationMap := brh.registry.NotificationMap()
+	if brh.router != nil {
+		maps.Copy(notificationMap, brh.router.NotificationMap())
+	}
+	return notificationMap
+}
+
+// Subscribe registers fn to be called after every change to the handlers served, in the registry or
+// in the router. See Registry.Subscribe and Router.Subscribe.
+func (brh *BatchRequestHandler) Subscribe(fn func(RegistryChange)) (unsubscribe func()) {
+	unsubscribeRegistry := brh.registry.Subscribe(fn)
+	if brh.router == nil {
+		return unsubscribeRegistry
+	}
+	unsubscribeRouter := brh.router.Subscribe(fn)
+	return func() {
+		unsubscribeRegistry()
+		unsubscribeRouter()
+	}
+}
+
+func (brh *BatchRequestHandler) lookupResponse(name string) (IResponseHandler, bool) {
+	if brh.router != nil {
+		if handler, ok := brh.router.LookupResponse(name); ok {
+			return handler, true
+		}
+	}
+	return brh.registry.LookupResponse(name)
 }
 
 func (brh *BatchRequestHandler) detectMessageType(u UnionRequest) (MessageType, *JSONRPCError) {
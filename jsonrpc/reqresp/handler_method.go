@@ -3,7 +3,6 @@ package reqresp
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"reflect"
 )
@@ -39,6 +38,11 @@ type IMethodHandler interface {
 //	}
 type MethodHandler[I any, O any] struct {
 	Endpoint func(ctx context.Context, params I) (O, error)
+	// ValidateParams enables validation of params against the schema of I before decoding them.
+	// See ParamsValidator for the supported struct tags.
+	ValidateParams bool
+
+	validator lazyParamsValidator
 }
 
 // Handle processes a request expecting a response.
@@ -46,7 +50,13 @@ func (m *MethodHandler[I, O]) Handle(
 	ctx context.Context,
 	req Request[json.RawMessage],
 ) Response[json.RawMessage] {
-	params, err := unmarshalData[I](req.Params)
+	if m.ValidateParams {
+		if err := m.validator.get(reflect.TypeOf((*I)(nil)).Elem()).Validate(req.Params); err != nil {
+			return invalidParamsResponse(req, err)
+		}
+	}
+	codec := GetCodec(ctx)
+	params, err := unmarshalData[I](codec, req.Params)
 	if err != nil {
 		// Return InvalidParamsError.
 		return invalidParamsResponse(req, err)
@@ -54,49 +64,7 @@ func (m *MethodHandler[I, O]) Handle(
 
 	// Call the handler.
 	result, err := m.Endpoint(ctx, params)
-	if err != nil {
-		// Check if err is a *jsonrpc.Error (JSON-RPC error).
-		var jsonrpcErr *JSONRPCError
-		if errors.As(err, &jsonrpcErr) {
-			// Handler returned a JSON-RPC error.
-			return Response[json.RawMessage]{
-				JSONRPC: JSONRPCVersion,
-				ID:      &req.ID,
-				Error:   jsonrpcErr,
-			}
-		}
-		// Handler returned a standard error.
-		return Response[json.RawMessage]{
-			JSONRPC: JSONRPCVersion,
-			ID:      &req.ID,
-			Error: &JSONRPCError{
-				Code:    InternalError,
-				Message: GetDefaultErrorMessage(InternalError) + ": " + err.Error(),
-			},
-		}
-	}
-
-	// Marshal the result.
-	resultData, err := json.Marshal(result)
-	if err != nil {
-		return Response[json.RawMessage]{
-			JSONRPC: JSONRPCVersion,
-			ID:      &req.ID,
-			Error: &JSONRPCError{
-				Code: InternalError,
-				Message: GetDefaultErrorMessage(
-					InternalError,
-				) + ": Error marshaling result: " + err.Error(),
-			},
-		}
-	}
-
-	// Return the response with the marshaled result.
-	return Response[json.RawMessage]{
-		JSONRPC: JSONRPCVersion,
-		ID:      &req.ID,
-		Result:  json.RawMessage(resultData),
-	}
+	return methodResponse(ctx, codec, req, result, err)
 }
 
 // GetTypes returns the reflect.Type of the input and output types.
@@ -109,10 +77,13 @@ func (m *MethodHandler[I, O]) GetTypes() (iType, oType reflect.Type) {
 func handleMethod(
 	ctx context.Context,
 	request UnionRequest,
-	methodMap map[string]IMethodHandler,
+	lookup func(string) (IMethodHandler, bool),
+	middlewares []MethodMiddleware,
 ) Response[json.RawMessage] {
-	handler, ok := methodMap[*request.Method]
-	if !ok {
+	handler, ok := lookup(*request.Method)
+	if !ok && request.ID != nil {
+		handler = methodNotFoundHandler{}
+	} else if !ok {
 		return Response[json.RawMessage]{
 			JSONRPC: JSONRPCVersion,
 			ID:      request.ID,
@@ -137,10 +108,83 @@ func handleMethod(
 		}
 	}
 	subCtx := contextWithRequestInfo(ctx, *request.Method, MessageTypeMethod, request.ID)
-	return handler.Handle(subCtx, Request[json.RawMessage]{
+	return chainMethod(handler, middlewares).Handle(subCtx, Request[json.RawMessage]{
 		JSONRPC: request.JSONRPC,
 		ID:      *request.ID,
 		Method:  *request.Method,
 		Params:  request.Params,
 	})
 }
+
+// methodNotFoundHandler answers the requests for unknown methods, so that they go through the method
+// middlewares like other requests.
+type methodNotFoundHandler struct{}
+
+func (methodNotFoundHandler) Handle(_ context.Context, req Request[json.RawMessage]) Response[json.RawMessage] {
+	return Response[json.RawMessage]{
+		JSONRPC: JSONRPCVersion,
+		ID:      &req.ID,
+		Error: &JSONRPCError{
+			Code:    MethodNotFoundError,
+			Message: GetDefaultErrorMessage(MethodNotFoundError) + ": " + req.Method,
+		},
+	}
+}
+
+// GetTypes returns the type of raw params and results, as unknown methods accept any params.
+func (methodNotFoundHandler) GetTypes() (iType, oType reflect.Type) {
+	rawType := reflect.TypeFor[json.RawMessage]()
+	return rawType, rawType
+}
+
+// methodResponse builds the response for a request from the result or error returned by an endpoint.
+func methodResponse(
+	ctx context.Context,
+	codec Codec,
+	req Request[json.RawMessage],
+	result any,
+	err error,
+) Response[json.RawMessage] {
+	if err != nil {
+		// Check if err is a *jsonrpc.Error (JSON-RPC error), or mapped to one.
+		if jsonrpcErr, ok := mapError(ctx, err); ok {
+			// Handler returned a JSON-RPC error.
+			return Response[json.RawMessage]{
+				JSONRPC: JSONRPCVersion,
+				ID:      &req.ID,
+				Error:   jsonrpcErr,
+			}
+		}
+		// Handler returned a standard error.
+		return Response[json.RawMessage]{
+			JSONRPC: JSONRPCVersion,
+			ID:      &req.ID,
+			Error: &JSONRPCError{
+				Code:    InternalError,
+				Message: GetDefaultErrorMessage(InternalError) + ": " + err.Error(),
+			},
+		}
+	}
+
+	// Marshal the result.
+	resultData, err := codec.Marshal(result)
+	if err != nil {
+		return Response[json.RawMessage]{
+			JSONRPC: JSONRPCVersion,
+			ID:      &req.ID,
+			Error: &JSONRPCError{
+				Code: InternalError,
+				Message: GetDefaultErrorMessage(
+					InternalError,
+				) + ": Error marshaling result: " + err.Error(),
+			},
+		}
+	}
+
+	// Return the response with the marshaled result.
+	return Response[json.RawMessage]{
+		JSONRPC: JSONRPCVersion,
+		ID:      &req.ID,
+		Result:  json.RawMessage(resultData),
+	}
+}
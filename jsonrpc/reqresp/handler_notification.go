@@ -38,6 +38,11 @@ type INotificationHandler interface {
 //	}
 type NotificationHandler[I any] struct {
 	Endpoint func(ctx context.Context, params I) error
+	// ValidateParams enables validation of params against the schema of I before decoding them.
+	// See ParamsValidator for the supported struct tags.
+	ValidateParams bool
+
+	validator lazyParamsValidator
 }
 
 // Handle processes a notification (no response expected).
@@ -45,7 +50,12 @@ func (n *NotificationHandler[I]) Handle(
 	ctx context.Context,
 	req Notification[json.RawMessage],
 ) error {
-	params, err := unmarshalData[I](req.Params)
+	if n.ValidateParams {
+		if err := n.validator.get(reflect.TypeOf((*I)(nil)).Elem()).Validate(req.Params); err != nil {
+			return err
+		}
+	}
+	params, err := unmarshalData[I](GetCodec(ctx), req.Params)
 	if err != nil {
 		// Cannot send error to client in notification; possibly log internally.
 		return err
@@ -60,22 +70,24 @@ func (m *NotificationHandler[I]) GetTypes() reflect.Type {
 	return reflect.TypeOf((*I)(nil)).Elem()
 }
 
+// handleNotification dispatches a notification. The handled result is false if no handler was found.
 func handleNotification(
 	ctx context.Context,
 	request UnionRequest,
-	notificationMap map[string]INotificationHandler,
-) error {
-	handler, ok := notificationMap[*request.Method]
+	lookup func(string) (INotificationHandler, bool),
+	middlewares []NotificationMiddleware,
+) (handled bool, err error) {
+	handler, ok := lookup(*request.Method)
 	if !ok {
-		return &JSONRPCError{
+		return false, &JSONRPCError{
 			Code: MethodNotFoundError,
 			Message: GetDefaultErrorMessage(
 				MethodNotFoundError,
-			) + ": Notification" + *request.Method,
+			) + ": Notification: " + *request.Method,
 		}
 	}
 	subCtx := contextWithRequestInfo(ctx, *request.Method, MessageTypeNotification, nil)
-	return handler.Handle(subCtx, Notification[json.RawMessage]{
+	return true, chainNotification(handler, middlewares).Handle(subCtx, Notification[json.RawMessage]{
 		JSONRPC: request.JSONRPC,
 		Method:  *request.Method,
 		Params:  request.Params,
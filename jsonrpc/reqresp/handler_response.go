@@ -56,7 +56,7 @@ func (r *ResponseHandler[T]) Handle(ctx context.Context, resp Response[json.RawM
 	}
 
 	// Unmarshal the result if present.
-	result, err := unmarshalData[T](resp.Result)
+	result, err := unmarshalData[T](GetCodec(ctx), resp.Result)
 	if err != nil {
 		return err
 	}
@@ -70,12 +70,15 @@ func (r *ResponseHandler[T]) GetTypes() reflect.Type {
 	return reflect.TypeOf((*T)(nil)).Elem()
 }
 
+// handleResponse dispatches a response to the handler of the method it is mapped to.
+// The handled result is false if the response could not be mapped to a handler.
 func handleResponse(
 	ctx context.Context,
 	request UnionRequest,
-	responseMap map[string]IResponseHandler,
+	lookup func(string) (IResponseHandler, bool),
 	responseHandlerMapper func(context.Context, Response[json.RawMessage]) (string, error),
-) error {
+	middlewares []ResponseMiddleware,
+) (method string, handled bool, err error) {
 	// Create context with request info.
 	resp := Response[json.RawMessage]{
 		JSONRPC: request.JSONRPC,
@@ -83,21 +86,21 @@ func handleResponse(
 		Result:  request.Result,
 		Error:   request.Error,
 	}
-	method, err := responseHandlerMapper(ctx, resp)
+	method, err = responseHandlerMapper(ctx, resp)
 	if err != nil {
-		return &JSONRPCError{
+		return method, false, &JSONRPCError{
 			Code:    InternalError,
 			Message: GetDefaultErrorMessage(InternalError) + ": " + err.Error(),
 		}
 	}
 	subCtx := contextWithRequestInfo(ctx, method, MessageTypeResponse, request.ID)
-	handler, ok := responseMap[method]
+	handler, ok := lookup(method)
 	if !ok {
-		return &JSONRPCError{
+		return method, false, &JSONRPCError{
 			Code:    MethodNotFoundError,
 			Message: GetDefaultErrorMessage(MethodNotFoundError) + ": " + method,
 		}
 	}
 
-	return handler.Handle(subCtx, resp)
+	return method, true, chainResponse(handler, middlewares).Handle(subCtx, resp)
 }
@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // AddParams defines the parameters for the "add" method.
@@ -595,3 +598,123 @@ func TestBatchRequestHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchRequestHandlerConcurrent(t *testing.T) {
+	const batchSize = 8
+
+	var running, maxRunning atomic.Int32
+	var notified atomic.Int32
+	// Every call waits until all calls of the batch have started, so a sequential
+	// dispatch would never release the barrier and time out instead.
+	barrier := make(chan struct{})
+	var started atomic.Int32
+	methodMap := map[string]IMethodHandler{
+		"barrier": &MethodHandler[AddParams, AddResult]{
+			Endpoint: func(ctx context.Context, params AddParams) (AddResult, error) {
+				if started.Add(1) == batchSize {
+					close(barrier)
+				}
+				select {
+				case <-barrier:
+				case <-time.After(5 * time.Second):
+					return AddResult{}, errors.New("batch was not processed concurrently")
+				}
+				return AddResult{Sum: params.A + params.B}, nil
+			},
+		},
+		"slow": &MethodHandler[AddParams, AddResult]{
+			Endpoint: func(ctx context.Context, params AddParams) (AddResult, error) {
+				cur := running.Add(1)
+				defer running.Add(-1)
+				for {
+					prev := maxRunning.Load()
+					if cur <= prev || maxRunning.CompareAndSwap(prev, cur) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return AddResult{Sum: params.A + params.B}, nil
+			},
+		},
+	}
+	notificationMap := map[string]INotificationHandler{
+		"notify": &NotificationHandler[NotifyParams]{
+			Endpoint: func(ctx context.Context, params NotifyParams) error {
+				notified.Add(1)
+				return nil
+			},
+		},
+	}
+
+	buildBatch := func(method string) *BatchRequest {
+		items := make([]UnionRequest, 0, batchSize+1)
+		for i := range batchSize {
+			items = append(items, UnionRequest{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer(method),
+				Params:  json.RawMessage(fmt.Sprintf(`{"a":%d,"b":1}`, i)),
+				ID:      &RequestID{Value: i},
+			})
+			if i == batchSize/2 {
+				items = append(items, UnionRequest{
+					JSONRPC: JSONRPCVersion,
+					Method:  stringToPointer("notify"),
+					Params:  json.RawMessage(`{"message":"hello"}`),
+				})
+			}
+		}
+		return &BatchRequest{Body: &BatchItem[UnionRequest]{IsBatch: true, Items: items}}
+	}
+
+	checkOrder := func(t *testing.T, resp *BatchResponse) {
+		t.Helper()
+		if resp == nil || resp.Body == nil || len(resp.Body.Items) != batchSize {
+			t.Fatalf("Expected %d responses, got %#v", batchSize, resp)
+		}
+		for i, item := range resp.Body.Items {
+			if !item.ID.Equal(&RequestID{Value: i}) {
+				t.Errorf("Response %d has id %v", i, item.ID.Value)
+			}
+			if item.Error != nil {
+				t.Errorf("Response %d has error %v", i, item.Error)
+				continue
+			}
+			if !jsonStringsEqual(string(item.Result), fmt.Sprintf(`{"sum":%d}`, i+1)) {
+				t.Errorf("Response %d has result %s", i, string(item.Result))
+			}
+		}
+	}
+
+	t.Run("Items run concurrently and keep order", func(t *testing.T) {
+		brh := NewBatchRequestHandler(
+			WithMethodMap(methodMap),
+			WithNotificationMap(notificationMap),
+			WithBatchConcurrency(batchSize),
+		)
+		resp, err := brh.Handle(t.Context(), buildBatch("barrier"))
+		if err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+		checkOrder(t, resp)
+		if notified.Load() != 1 {
+			t.Errorf("Expected notification to be handled once, got %d", notified.Load())
+		}
+	})
+
+	t.Run("Global in-flight cap is honored", func(t *testing.T) {
+		brh := NewBatchRequestHandler(
+			WithMethodMap(methodMap),
+			WithNotificationMap(notificationMap),
+			WithBatchConcurrency(batchSize),
+			WithMaxInFlight(2),
+		)
+		resp, err := brh.Handle(t.Context(), buildBatch("slow"))
+		if err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+		checkOrder(t, resp)
+		if got := maxRunning.Load(); got > 2 {
+			t.Errorf("Expected at most 2 items in flight, got %d", got)
+		}
+	})
+}
@@ -3,14 +3,18 @@ package reqresp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"reflect"
 )
 
 type contextKey string
 
 const (
-	ctxKeyRequestID   contextKey = "jsonrpcRequestID"
-	ctxKeyMethodName  contextKey = "jsonrpcMethodName"
-	ctxKeyMessageType contextKey = "jsonrpcMessageType"
+	ctxKeyRequestID     contextKey = "jsonrpcRequestID"
+	ctxKeyMethodName    contextKey = "jsonrpcMethodName"
+	ctxKeyMessageType   contextKey = "jsonrpcMessageType"
+	ctxKeyCodec         contextKey = "jsonrpcCodec"
+	ctxKeyErrorMappings contextKey = "jsonrpcErrorMappings"
 )
 
 // GetRequestID retrieves the RequestID from the context.
@@ -47,25 +51,42 @@ func contextWithRequestInfo(
 }
 
 // Helper function to unmarshal generic data.
-func unmarshalData[I any](data json.RawMessage) (I, error) {
+func unmarshalData[I any](codec Codec, data json.RawMessage) (I, error) {
 	var p I
-	if data == nil {
-		return p, nil
-	}
-	if err := json.Unmarshal(data, &p); err != nil {
+	if err := unmarshalInto(codec, data, &p); err != nil {
 		return p, err
 	}
 	return p, nil
 }
 
+// Helper function to unmarshal data into a pointer target. Absent data leaves the target untouched.
+// Arrays are bound by position if the target struct declares positional fields.
+func unmarshalInto(codec Codec, data json.RawMessage, target any) error {
+	if data == nil {
+		return nil
+	}
+	if isJSONArray(data) {
+		if layout := getPositionalLayout(reflect.TypeOf(target)); layout != nil {
+			return bindPositional(codec, data, target, layout)
+		}
+	}
+	return codec.Unmarshal(data, target)
+}
+
 // Helper function to create an InvalidParamsError response.
+// Validation failures are listed in the error data.
 func invalidParamsResponse(req Request[json.RawMessage], err error) Response[json.RawMessage] {
+	jsonrpcErr := &JSONRPCError{
+		Code:    InvalidParamsError,
+		Message: GetDefaultErrorMessage(InvalidParamsError) + ": " + err.Error(),
+	}
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		jsonrpcErr.Data = validationErrs
+	}
 	return Response[json.RawMessage]{
 		JSONRPC: JSONRPCVersion,
 		ID:      &req.ID,
-		Error: &JSONRPCError{
-			Code:    InvalidParamsError,
-			Message: GetDefaultErrorMessage(InvalidParamsError) + ": " + err.Error(),
-		},
+		Error:   jsonrpcErr,
 	}
 }
@@ -0,0 +1,172 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// MethodMiddleware wraps a method handler to add cross-cutting behavior.
+// The wrapped handler receives the full request, i.e. method name, id and raw params,
+// and sees the response returned by the next handler.
+//
+// Example:
+//
+//	logging := func(next IMethodHandler) IMethodHandler {
+//	    return WrapMethodHandler(next, func(ctx context.Context, req Request[json.RawMessage]) Response[json.RawMessage] {
+//	        resp := next.Handle(ctx, req)
+//	        log.Printf("method: %s, params: %s, error: %v", req.Method, req.Params, resp.Error)
+//	        return resp
+//	    })
+//	}
+type MethodMiddleware func(next IMethodHandler) IMethodHandler
+
+// NotificationMiddleware wraps a notification handler to add cross-cutting behavior.
+type NotificationMiddleware func(next INotificationHandler) INotificationHandler
+
+// ResponseMiddleware wraps a response handler to add cross-cutting behavior.
+// The method the response was mapped to is available via GetMethodName.
+type ResponseMiddleware func(next IResponseHandler) IResponseHandler
+
+// BatchHandlerFunc handles a whole batch, as BatchRequestHandler.Handle does.
+type BatchHandlerFunc func(ctx context.Context, req *BatchRequest) (*BatchResponse, error)
+
+// BatchMiddleware wraps the handling of a whole batch, e.g. to trace or time it.
+// Single requests are batches with IsBatch false.
+type BatchMiddleware func(next BatchHandlerFunc) BatchHandlerFunc
+
+// WithBatchMiddleware appends middlewares that wrap the handling of every batch.
+// The first middleware is the outermost one.
+func WithBatchMiddleware(middlewares ...BatchMiddleware) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.batchMiddlewares = append(h.batchMiddlewares, middlewares...)
+	}
+}
+
+// WithMethodMiddleware appends middlewares that wrap every method handler.
+// The first middleware is the outermost one. Requests for unknown methods go through the middlewares
+// too, with a handler answering MethodNotFoundError.
+func WithMethodMiddleware(middlewares ...MethodMiddleware) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.methodMiddlewares = append(h.methodMiddlewares, middlewares...)
+	}
+}
+
+// WithNotificationMiddleware appends middlewares that wrap every notification handler.
+// The first middleware is the outermost one. Unknown notifications skip the middlewares, they are
+// reported to observers as EventNotificationNotFound.
+func WithNotificationMiddleware(middlewares ...NotificationMiddleware) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.notificationMiddlewares = append(h.notificationMiddlewares, middlewares...)
+	}
+}
+
+// WithResponseMiddleware appends middlewares that wrap every response handler.
+// The first middleware is the outermost one.
+func WithResponseMiddleware(middlewares ...ResponseMiddleware) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.responseMiddlewares = append(h.responseMiddlewares, middlewares...)
+	}
+}
+
+// WrapMethodHandler returns a method handler that runs fn and reports the types of next.
+// It is a helper to write middlewares without redeclaring GetTypes.
+func WrapMethodHandler(
+	next IMethodHandler,
+	fn func(ctx context.Context, req Request[json.RawMessage]) Response[json.RawMessage],
+) IMethodHandler {
+	return &methodHandlerFunc{next: next, fn: fn}
+}
+
+// WrapNotificationHandler returns a notification handler that runs fn and reports the types of next.
+func WrapNotificationHandler(
+	next INotificationHandler,
+	fn func(ctx context.Context, req Notification[json.RawMessage]) error,
+) INotificationHandler {
+	return &notificationHandlerFunc{next: next, fn: fn}
+}
+
+// WrapResponseHandler returns a response handler that runs fn and reports the types of next.
+func WrapResponseHandler(
+	next IResponseHandler,
+	fn func(ctx context.Context, resp Response[json.RawMessage]) error,
+) IResponseHandler {
+	return &responseHandlerFunc{next: next, fn: fn}
+}
+
+type methodHandlerFunc struct {
+	next IMethodHandler
+	fn   func(ctx context.Context, req Request[json.RawMessage]) Response[json.RawMessage]
+}
+
+func (m *methodHandlerFunc) Handle(
+	ctx context.Context,
+	req Request[json.RawMessage],
+) Response[json.RawMessage] {
+	return m.fn(ctx, req)
+}
+
+func (m *methodHandlerFunc) GetTypes() (iType, oType reflect.Type) {
+	return m.next.GetTypes()
+}
+
+type notificationHandlerFunc struct {
+	next INotificationHandler
+	fn   func(ctx context.Context, req Notification[json.RawMessage]) error
+}
+
+func (n *notificationHandlerFunc) Handle(ctx context.Context, req Notification[json.RawMessage]) error {
+	return n.fn(ctx, req)
+}
+
+func (n *notificationHandlerFunc) GetTypes() reflect.Type {
+	return n.next.GetTypes()
+}
+
+type responseHandlerFunc struct {
+	next IResponseHandler
+	fn   func(ctx context.Context, resp Response[json.RawMessage]) error
+}
+
+func (r *responseHandlerFunc) Handle(ctx context.Context, resp Response[json.RawMessage]) error {
+	return r.fn(ctx, resp)
+}
+
+func (r *responseHandlerFunc) GetTypes() reflect.Type {
+	return r.next.GetTypes()
+}
+
+// chainBatch wraps handler with the middlewares, the first one being the outermost.
+func chainBatch(handler BatchHandlerFunc, middlewares []BatchMiddleware) BatchHandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// chainMethod wraps handler with the middlewares, the first one being the outermost.
+func chainMethod(handler IMethodHandler, middlewares []MethodMiddleware) IMethodHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// chainNotification wraps handler with the middlewares, the first one being the outermost.
+func chainNotification(
+	handler INotificationHandler,
+	middlewares []NotificationMiddleware,
+) INotificationHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// chainResponse wraps handler with the middlewares, the first one being the outermost.
+func chainResponse(handler IResponseHandler, middlewares []ResponseMiddleware) IResponseHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,219 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMiddlewares(t *testing.T) {
+	var calls []string
+	record := func(name string) MethodMiddleware {
+		return func(next IMethodHandler) IMethodHandler {
+			return WrapMethodHandler(
+				next,
+				func(ctx context.Context, req Request[json.RawMessage]) Response[json.RawMessage] {
+					calls = append(calls, name+":before:"+req.Method+":"+string(req.Params))
+					resp := next.Handle(ctx, req)
+					outcome := string(resp.Result)
+					if resp.Error != nil {
+						outcome = resp.Error.Message
+					}
+					calls = append(calls, name+":after:"+outcome)
+					return resp
+				},
+			)
+		}
+	}
+	deny := func(next IMethodHandler) IMethodHandler {
+		return WrapMethodHandler(
+			next,
+			func(ctx context.Context, req Request[json.RawMessage]) Response[json.RawMessage] {
+				if req.Method != "denied" {
+					return next.Handle(ctx, req)
+				}
+				return Response[json.RawMessage]{
+					JSONRPC: JSONRPCVersion,
+					ID:      &req.ID,
+					Error:   &JSONRPCError{Code: -32001, Message: "Unauthorized"},
+				}
+			},
+		)
+	}
+
+	var notified []string
+	notificationMW := func(next INotificationHandler) INotificationHandler {
+		return WrapNotificationHandler(
+			next,
+			func(ctx context.Context, req Notification[json.RawMessage]) error {
+				err := next.Handle(ctx, req)
+				notified = append(notified, req.Method+":"+string(req.Params)+":"+err.Error())
+				return err
+			},
+		)
+	}
+
+	var responded []string
+	responseMW := func(next IResponseHandler) IResponseHandler {
+		return WrapResponseHandler(next, func(ctx context.Context, resp Response[json.RawMessage]) error {
+			method, _ := GetMethodName(ctx)
+			responded = append(responded, method+":"+string(resp.Result))
+			return next.Handle(ctx, resp)
+		})
+	}
+
+	brh := NewBatchRequestHandler(
+		WithMethodMap(map[string]IMethodHandler{
+			"add":    &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+			"denied": &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+		}),
+		WithNotificationMap(map[string]INotificationHandler{
+			"fail": &NotificationHandler[NotifyParams]{
+				Endpoint: func(ctx context.Context, params NotifyParams) error {
+					return errors.New("failed")
+				},
+			},
+		}),
+		WithResponseMap(
+			map[string]IResponseHandler{
+				"add": &ResponseHandler[AddResult]{Endpoint: AddResponseEndpoint},
+			},
+			func(context.Context, Response[json.RawMessage]) (string, error) {
+				return "add", nil
+			},
+		),
+		WithMethodMiddleware(record("outer"), record("inner")),
+		WithMethodMiddleware(deny),
+		WithNotificationMiddleware(notificationMW),
+		WithResponseMiddleware(responseMW),
+	)
+
+	resp, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+		IsBatch: true,
+		Items: []UnionRequest{
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("add"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+				ID:      &RequestID{Value: 1},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("denied"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+				ID:      &RequestID{Value: 2},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("missing"),
+				ID:      &RequestID{Value: 4},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("fail"),
+				Params:  json.RawMessage(`{"message":"hi"}`),
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Result:  json.RawMessage(`{"sum":3}`),
+				ID:      &RequestID{Value: 3},
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if len(resp.Body.Items) != 3 {
+		t.Fatalf("Expected 3 responses, got %d", len(resp.Body.Items))
+	}
+	if resp.Body.Items[1].Error == nil || resp.Body.Items[1].Error.Code != -32001 {
+		t.Errorf("Expected denied method to be rejected by middleware, got %#v", resp.Body.Items[1])
+	}
+	if resp.Body.Items[2].Error == nil || resp.Body.Items[2].Error.Code != MethodNotFoundError {
+		t.Errorf("Expected unknown method to be not found, got %#v", resp.Body.Items[2])
+	}
+
+	wantCalls := []string{
+		`outer:before:add:{"a":1,"b":2}`,
+		`inner:before:add:{"a":1,"b":2}`,
+		`inner:after:{"sum":3}`,
+		`outer:after:{"sum":3}`,
+		`outer:before:denied:{"a":1,"b":2}`,
+		`inner:before:denied:{"a":1,"b":2}`,
+		`inner:after:Unauthorized`,
+		`outer:after:Unauthorized`,
+		// Unknown methods go through the middlewares too.
+		`outer:before:missing:`,
+		`inner:before:missing:`,
+		`inner:after:` + GetDefaultErrorMessage(MethodNotFoundError) + `: missing`,
+		`outer:after:` + GetDefaultErrorMessage(MethodNotFoundError) + `: missing`,
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("Unexpected method middleware calls.\nGot:  %q\nWant: %q", calls, wantCalls)
+	}
+	if want := []string{`fail:{"message":"hi"}:failed`}; !reflect.DeepEqual(notified, want) {
+		t.Errorf("Unexpected notification middleware calls. Got: %q, Want: %q", notified, want)
+	}
+	if want := []string{`add:{"sum":3}`}; !reflect.DeepEqual(responded, want) {
+		t.Errorf("Unexpected response middleware calls. Got: %q, Want: %q", responded, want)
+	}
+}
+
+func TestWrappedHandlerKeepsTypes(t *testing.T) {
+	next := &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint}
+	wrapped := WrapMethodHandler(next, next.Handle)
+	iType, oType := wrapped.GetTypes()
+	if iType != reflect.TypeOf(AddParams{}) || oType != reflect.TypeOf(AddResult{}) {
+		t.Errorf("Expected wrapped handler to report the types of next, got %v, %v", iType, oType)
+	}
+}
+
+func TestBatchMiddleware(t *testing.T) {
+	var calls []string
+	record := func(name string) BatchMiddleware {
+		return func(next BatchHandlerFunc) BatchHandlerFunc {
+			return func(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+				calls = append(calls, name+":before")
+				resp, err := next(ctx, req)
+				if resp.Body != nil {
+					calls = append(calls, name+":after:"+strconv.Itoa(len(resp.Body.Items)))
+				}
+				return resp, err
+			}
+		}
+	}
+	handler := NewBatchRequestHandler(
+		WithMethodMap(map[string]IMethodHandler{
+			"add": &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+		}),
+		WithBatchMiddleware(record("outer"), record("inner")),
+	)
+
+	_, err := handler.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+		IsBatch: true,
+		Items: []UnionRequest{
+			{
+				JSONRPC: JSONRPCVersion,
+				ID:      &RequestID{Value: 1},
+				Method:  stringToPointer("add"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				ID:      &RequestID{Value: 2},
+				Method:  stringToPointer("add"),
+				Params:  json.RawMessage(`{"a":3,"b":4}`),
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	want := []string{"outer:before", "inner:before", "inner:after:2", "outer:after:2"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+}
@@ -0,0 +1,83 @@
+package reqresp
+
+import (
+	"context"
+	"errors"
+)
+
+type EventKind string
+
+// Constants for the kinds of events reported to observers.
+const (
+	// EventNotificationFailed is reported when a notification handler returns an error.
+	EventNotificationFailed EventKind = "NotificationFailed"
+	// EventNotificationNotFound is reported for a notification without a registered handler.
+	EventNotificationNotFound EventKind = "NotificationNotFound"
+	// EventResponseUnmatched is reported for a response that cannot be mapped to a response handler.
+	EventResponseUnmatched EventKind = "ResponseUnmatched"
+	// EventResponseFailed is reported when a response handler returns an error.
+	EventResponseFailed EventKind = "ResponseFailed"
+	// EventMessageSkipped is reported for a message that is not processed, e.g. because the
+	// context was done before it could be processed.
+	EventMessageSkipped EventKind = "MessageSkipped"
+)
+
+// errNoResponseMapper is the cause of EventResponseUnmatched if the handler has no response mapper.
+var errNoResponseMapper = errors.New("no response handler mapper configured")
+
+// Event describes a message whose outcome cannot be reported back to the peer.
+type Event struct {
+	Kind        EventKind
+	MessageType MessageType
+	// Method name, empty if it is not known, e.g. for unmatched responses.
+	Method string
+	// ID of the message, nil for notifications.
+	ID *RequestID
+	// Error explaining the event.
+	Cause error
+}
+
+// Observer receives events for messages that would otherwise be dropped silently.
+// Observe may be called concurrently if batch items are processed concurrently.
+type Observer interface {
+	Observe(ctx context.Context, event Event)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(ctx context.Context, event Event)
+
+// Observe calls f.
+func (f ObserverFunc) Observe(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+// WithObserver adds observers notified of dropped notifications, unmatched responses and
+// failures of handlers that cannot return an error to the peer.
+//
+// Example:
+//
+//	handler := NewBatchRequestHandler(
+//	    WithObserver(ObserverFunc(func(ctx context.Context, event Event) {
+//	        log.Printf("%s %s: %v", event.Kind, event.Method, event.Cause)
+//	    })),
+//	)
+func WithObserver(observers ...Observer) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.observers = append(h.observers, observers...)
+	}
+}
+
+func newEvent(kind EventKind, msgType MessageType, request UnionRequest, cause error) Event {
+	event := Event{Kind: kind, MessageType: msgType, ID: request.ID, Cause: cause}
+	if request.Method != nil {
+		event.Method = *request.Method
+	}
+	return event
+}
+
+// observe reports event to all observers.
+func (brh *BatchRequestHandler) observe(ctx context.Context, event Event) {
+	for _, o := range brh.observers {
+		o.Observe(ctx, event)
+	}
+}
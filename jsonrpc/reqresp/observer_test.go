@@ -0,0 +1,117 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestObserver(t *testing.T) {
+	errFailed := errors.New("failed")
+	notificationMap := map[string]INotificationHandler{
+		"ping": &NotificationHandler[PingParams]{Endpoint: PingEndpoint},
+		"fail": &NotificationHandler[PingParams]{
+			Endpoint: func(ctx context.Context, params PingParams) error {
+				return errFailed
+			},
+		},
+	}
+	responseMap := map[string]IResponseHandler{
+		"add": &ResponseHandler[AddResult]{
+			Endpoint: func(ctx context.Context, result *AddResult, err *JSONRPCError) error {
+				return errFailed
+			},
+		},
+	}
+	mapper := func(ctx context.Context, resp Response[json.RawMessage]) (string, error) {
+		if resp.ID.Equal(&RequestID{Value: 1}) {
+			return "add", nil
+		}
+		return "unknown", nil
+	}
+	items := []UnionRequest{
+		{JSONRPC: JSONRPCVersion, Method: stringToPointer("ping"), Params: json.RawMessage(`{"message":"hi"}`)},
+		{JSONRPC: JSONRPCVersion, Method: stringToPointer("fail"), Params: json.RawMessage(`{"message":"hi"}`)},
+		{JSONRPC: JSONRPCVersion, Method: stringToPointer("missing")},
+		{JSONRPC: JSONRPCVersion, Result: json.RawMessage(`{"sum":3}`), ID: &RequestID{Value: 1}},
+		{JSONRPC: JSONRPCVersion, Result: json.RawMessage(`{"sum":3}`), ID: &RequestID{Value: 2}},
+	}
+
+	tests := []struct {
+		name string
+		opts []HandlerOption
+		want []Event
+	}{
+		{
+			name: "With response mapper",
+			opts: []HandlerOption{WithResponseMap(responseMap, mapper)},
+			want: []Event{
+				{Kind: EventNotificationFailed, MessageType: MessageTypeNotification, Method: "fail"},
+				{Kind: EventNotificationNotFound, MessageType: MessageTypeNotification, Method: "missing"},
+				{
+					Kind:        EventResponseFailed,
+					MessageType: MessageTypeResponse,
+					Method:      "add",
+					ID:          &RequestID{Value: 1},
+				},
+				{
+					Kind:        EventResponseUnmatched,
+					MessageType: MessageTypeResponse,
+					Method:      "unknown",
+					ID:          &RequestID{Value: 2},
+				},
+			},
+		},
+		{
+			name: "Without response mapper",
+			want: []Event{
+				{Kind: EventNotificationFailed, MessageType: MessageTypeNotification, Method: "fail"},
+				{Kind: EventNotificationNotFound, MessageType: MessageTypeNotification, Method: "missing"},
+				{Kind: EventResponseUnmatched, MessageType: MessageTypeResponse, ID: &RequestID{Value: 1}},
+				{Kind: EventResponseUnmatched, MessageType: MessageTypeResponse, ID: &RequestID{Value: 2}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []Event
+			opts := append([]HandlerOption{
+				WithNotificationMap(notificationMap),
+				WithObserver(ObserverFunc(func(ctx context.Context, event Event) {
+					events = append(events, event)
+				})),
+			}, tc.opts...)
+			brh := NewBatchRequestHandler(opts...)
+
+			resp, err := brh.Handle(
+				t.Context(),
+				&BatchRequest{Body: &BatchItem[UnionRequest]{IsBatch: true, Items: items}},
+			)
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			if resp.Body != nil {
+				t.Fatalf("Expected no responses, got %#v", resp.Body.Items)
+			}
+
+			if len(events) != len(tc.want) {
+				t.Fatalf("Expected %d events, got %d: %#v", len(tc.want), len(events), events)
+			}
+			for i, want := range tc.want {
+				got := events[i]
+				if got.Kind != want.Kind || got.MessageType != want.MessageType ||
+					got.Method != want.Method || !got.ID.Equal(want.ID) {
+					t.Errorf("Unexpected event %d. Got: %#v, Want: %#v", i, got, want)
+				}
+				if got.Cause == nil {
+					t.Errorf("Expected event %d to have a cause", i)
+				}
+			}
+			if !errors.Is(events[0].Cause, errFailed) {
+				t.Errorf("Expected notification failure cause to be the endpoint error, got %v", events[0].Cause)
+			}
+		})
+	}
+}
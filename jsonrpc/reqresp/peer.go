@@ -0,0 +1,252 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPeerClosed is returned by calls of a Peer that is closed.
+var ErrPeerClosed = errors.New("peer closed")
+
+// SendFunc writes a single encoded message to the remote peer.
+type SendFunc func(ctx context.Context, msg []byte) error
+
+// Peer is one end of a bidirectional JSON-RPC connection.
+// It serves incoming requests and notifications with a BatchRequestHandler, and makes outgoing
+// calls whose responses are matched by ID, so no response handler mapper is needed for them.
+//
+// Example:
+//
+//	peer := NewPeer(handler, func(ctx context.Context, msg []byte) error {
+//	    return conn.Write(msg)
+//	}, WithCallTimeout(30*time.Second))
+//
+//	// In the read loop.
+//	out, err := peer.HandleMessage(ctx, msg)
+//	if err == nil && out != nil {
+//	    _ = conn.Write(out)
+//	}
+//
+//	// Anywhere else.
+//	var roots ListRootsResult
+//	err := peer.Call(ctx, "roots/list", nil, &roots)
+type Peer struct {
+	handler *BatchRequestHandler
+	send    SendFunc
+	timeout time.Duration
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	closed  bool
+	pending map[string]chan Response[json.RawMessage]
+}
+
+// PeerOption configures a Peer.
+type PeerOption func(*Peer)
+
+// WithCallTimeout bounds the time a call waits for its response. Values <= 0 wait until the
+// context of the call is done, which is the default.
+func WithCallTimeout(timeout time.Duration) PeerOption {
+	return func(p *Peer) {
+		p.timeout = timeout
+	}
+}
+
+// NewPeer creates a peer that serves incoming messages with handler and sends outgoing ones with send.
+// A nil handler creates one without handlers, which only accepts responses to calls.
+func NewPeer(handler *BatchRequestHandler, send SendFunc, opts ...PeerOption) *Peer {
+	if handler == nil {
+		handler = NewBatchRequestHandler()
+	}
+	p := &Peer{
+		handler: handler,
+		send:    send,
+		pending: make(map[string]chan Response[json.RawMessage]),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Handler returns the handler serving incoming messages.
+func (p *Peer) Handler() *BatchRequestHandler {
+	return p.handler
+}
+
+// HandleMessage processes a message received from the remote peer.
+// Responses to pending calls are delivered to their callers, all other items are processed by
+// the handler. It returns the encoded response to send back, nil if there is none.
+func (p *Peer) HandleMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	var batch BatchItem[UnionRequest]
+	if err := DefaultCodec().Unmarshal(msg, &batch); err != nil {
+		var jerr *JSONRPCError
+		if !errors.As(err, &jerr) {
+			jerr = &JSONRPCError{
+				Code:    ParseError,
+				Message: GetDefaultErrorMessage(ParseError) + ": " + err.Error(),
+			}
+		}
+		return DefaultCodec().Marshal(Response[json.RawMessage]{JSONRPC: JSONRPCVersion, Error: jerr})
+	}
+
+	items := make([]UnionRequest, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		if !p.deliver(item) {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	resp, err := p.handler.Handle(ctx, &BatchRequest{
+		Body: &BatchItem[UnionRequest]{IsBatch: batch.IsBatch, Items: items},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil {
+		return nil, nil
+	}
+	return DefaultCodec().Marshal(resp.Body)
+}
+
+// deliver hands a response to the pending call with its ID. It reports whether there was one.
+func (p *Peer) deliver(item UnionRequest) bool {
+	if item.Method != nil || item.ID == nil || (item.Result == nil && item.Error == nil) {
+		return false
+	}
+	p.mu.Lock()
+	ch, ok := p.pending[item.ID.Key()]
+	delete(p.pending, item.ID.Key())
+	p.mu.Unlock()
+	if !ok {
+		return false
+	}
+	ch <- Response[json.RawMessage]{
+		JSONRPC: item.JSONRPC,
+		ID:      item.ID,
+		Result:  item.Result,
+		Error:   item.Error,
+	}
+	return true
+}
+
+// Call sends a request and waits for its response.
+// If result is not nil, the result of the response is decoded into it.
+// An error response is returned as a *JSONRPCError.
+func (p *Peer) Call(ctx context.Context, method string, params, result any) error {
+	rawParams, err := p.encodeParams(params)
+	if err != nil {
+		return err
+	}
+	id := RequestID{Value: int(p.nextID.Add(1))}
+	key := id.Key()
+	ch := make(chan Response[json.RawMessage], 1)
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPeerClosed
+	}
+	p.pending[key] = ch
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, key)
+		p.mu.Unlock()
+	}()
+
+	msg, err := DefaultCodec().Marshal(Request[json.RawMessage]{
+		JSONRPC: JSONRPCVersion,
+		ID:      id,
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return err
+	}
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	if err := p.send(ctx, msg); err != nil {
+		return fmt.Errorf("send %s: %w", method, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return ErrPeerClosed
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return p.codec().Unmarshal(resp.Result, result)
+	case <-ctx.Done():
+		return fmt.Errorf("call %s: %w", method, ctx.Err())
+	}
+}
+
+// Notify sends a notification.
+func (p *Peer) Notify(ctx context.Context, method string, params any) error {
+	rawParams, err := p.encodeParams(params)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return ErrPeerClosed
+	}
+	msg, err := DefaultCodec().Marshal(Notification[json.RawMessage]{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return err
+	}
+	return p.send(ctx, msg)
+}
+
+// Close fails all pending calls with ErrPeerClosed, and rejects new ones.
+func (p *Peer) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for key, ch := range p.pending {
+		close(ch)
+		delete(p.pending, key)
+	}
+}
+
+func (p *Peer) codec() Codec {
+	if p.handler.codec != nil {
+		return p.handler.codec
+	}
+	return DefaultCodec()
+}
+
+func (p *Peer) encodeParams(params any) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	data, err := p.codec().Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("encode params: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,137 @@
+package reqresp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// connectPeers returns two peers whose messages are delivered to each other asynchronously.
+func connectPeers(
+	t *testing.T,
+	serverHandler, clientHandler *BatchRequestHandler,
+	opts ...PeerOption,
+) (server, client *Peer) {
+	t.Helper()
+	var wg sync.WaitGroup
+	t.Cleanup(wg.Wait)
+	deliverTo := func(target **Peer, replyTo **Peer) SendFunc {
+		return func(ctx context.Context, msg []byte) error {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				out, err := (*target).HandleMessage(context.Background(), msg)
+				if err != nil {
+					t.Errorf("HandleMessage returned error: %v", err)
+					return
+				}
+				if out != nil {
+					if _, err := (*replyTo).HandleMessage(context.Background(), out); err != nil {
+						t.Errorf("HandleMessage returned error: %v", err)
+					}
+				}
+			}()
+			return nil
+		}
+	}
+	server = NewPeer(serverHandler, deliverTo(&client, &server), opts...)
+	client = NewPeer(clientHandler, deliverTo(&server, &client), opts...)
+	return server, client
+}
+
+func TestPeerCall(t *testing.T) {
+	clientHandler := NewBatchRequestHandler(
+		WithMethodMap(map[string]IMethodHandler{
+			"add": &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+			"fail": &MethodHandler[AddParams, AddResult]{
+				Endpoint: func(ctx context.Context, params AddParams) (AddResult, error) {
+					return AddResult{}, NewError(-32001, nil)
+				},
+			},
+		}),
+		WithBatchConcurrency(4),
+	)
+	server, _ := connectPeers(t, nil, clientHandler)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result AddResult
+			if err := server.Call(t.Context(), "add", AddParams{A: i, B: 1}, &result); err != nil {
+				t.Errorf("Call returned error: %v", err)
+				return
+			}
+			if result.Sum != i+1 {
+				t.Errorf("Expected sum %d, got %d", i+1, result.Sum)
+			}
+		}()
+	}
+	wg.Wait()
+
+	err := server.Call(t.Context(), "fail", AddParams{}, nil)
+	if !errors.Is(err, JSONRPCErrorCode(-32001)) {
+		t.Errorf("Expected error response to be returned, got %v", err)
+	}
+	err = server.Call(t.Context(), "missing", nil, nil)
+	if !errors.Is(err, MethodNotFoundError) {
+		t.Errorf("Expected method not found, got %v", err)
+	}
+}
+
+func TestPeerCallTimeoutAndClose(t *testing.T) {
+	release := make(chan struct{})
+	clientHandler := NewBatchRequestHandler(WithMethodMap(map[string]IMethodHandler{
+		"slow": &MethodHandler[struct{}, struct{}]{
+			Endpoint: func(ctx context.Context, _ struct{}) (struct{}, error) {
+				<-release
+				return struct{}{}, nil
+			},
+		},
+	}))
+	var events []Event
+	var mu sync.Mutex
+	serverHandler := NewBatchRequestHandler(WithObserver(ObserverFunc(func(ctx context.Context, event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})))
+	server, _ := connectPeers(t, serverHandler, clientHandler, WithCallTimeout(20*time.Millisecond))
+
+	err := server.Call(t.Context(), "slow", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected call to time out, got %v", err)
+	}
+	server.mu.Lock()
+	pending := len(server.pending)
+	server.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("Expected timed out call to be removed from the pending table, got %d", pending)
+	}
+
+	// The late response is reported as unmatched.
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(events)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	if len(events) != 1 || events[0].Kind != EventResponseUnmatched {
+		t.Errorf("Expected late response to be reported as unmatched, got %#v", events)
+	}
+	mu.Unlock()
+
+	server.Close()
+	if err := server.Call(t.Context(), "slow", nil, nil); !errors.Is(err, ErrPeerClosed) {
+		t.Errorf("Expected call on closed peer to fail, got %v", err)
+	}
+}
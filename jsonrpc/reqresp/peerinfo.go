@@ -0,0 +1,67 @@
+package reqresp
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	ctxKeyPeerInfo  contextKey = "jsonrpcPeerInfo"
+	ctxKeyPrincipal contextKey = "jsonrpcPrincipal"
+)
+
+// PeerInfo describes the peer a message was received from. It is set in the context by the transports,
+// so that handlers can read it regardless of the transport serving them.
+type PeerInfo struct {
+	// Transport is the name of the transport, e.g. "http", "sse" or "stdio".
+	Transport string
+	// RemoteAddr is the address of the peer, e.g. the client address of an HTTP request or the
+	// address of a stdio connection.
+	RemoteAddr string
+	// Header holds the headers of the request, empty for transports without headers.
+	Header http.Header
+	// SessionID is the session of the peer for transports with sessions, e.g. SSE.
+	SessionID string
+}
+
+// ContextWithPeerInfo returns a context holding info.
+func ContextWithPeerInfo(ctx context.Context, info PeerInfo) context.Context {
+	return context.WithValue(ctx, ctxKeyPeerInfo, info)
+}
+
+// GetPeerInfo retrieves the PeerInfo from the context.
+func GetPeerInfo(ctx context.Context) (PeerInfo, bool) {
+	info, ok := ctx.Value(ctxKeyPeerInfo).(PeerInfo)
+	return info, ok
+}
+
+// GetRemoteAddr retrieves the address of the peer from the context.
+func GetRemoteAddr(ctx context.Context) (string, bool) {
+	info, ok := GetPeerInfo(ctx)
+	return info.RemoteAddr, ok && info.RemoteAddr != ""
+}
+
+// GetHeader retrieves the first value of the request header key from the context.
+// It is empty if the header is not set or the transport has no headers.
+func GetHeader(ctx context.Context, key string) string {
+	info, _ := GetPeerInfo(ctx)
+	return info.Header.Get(key)
+}
+
+// GetSessionID retrieves the session of the peer from the context.
+func GetSessionID(ctx context.Context) (string, bool) {
+	info, ok := GetPeerInfo(ctx)
+	return info.SessionID, ok && info.SessionID != ""
+}
+
+// ContextWithPrincipal returns a context holding the authenticated identity of the peer.
+// It is meant for authentication middlewares, e.g. reading a token with GetHeader.
+func ContextWithPrincipal(ctx context.Context, principal any) context.Context {
+	return context.WithValue(ctx, ctxKeyPrincipal, principal)
+}
+
+// GetPrincipal retrieves the authenticated identity of the peer from the context, if it is a T.
+func GetPrincipal[T any](ctx context.Context) (T, bool) {
+	principal, ok := ctx.Value(ctxKeyPrincipal).(T)
+	return principal, ok
+}
@@ -0,0 +1,51 @@
+package reqresp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type testPrincipal struct {
+	Name string
+}
+
+func TestPeerInfo(t *testing.T) {
+	ctx := t.Context()
+	if _, ok := GetPeerInfo(ctx); ok {
+		t.Errorf("Expected no peer info")
+	}
+	if _, ok := GetRemoteAddr(ctx); ok {
+		t.Errorf("Expected no remote address")
+	}
+	if got := GetHeader(ctx, "Authorization"); got != "" {
+		t.Errorf("Expected no header, got %q", got)
+	}
+
+	ctx = ContextWithPeerInfo(ctx, PeerInfo{
+		Transport:  "sse",
+		RemoteAddr: "127.0.0.1:1234",
+		Header:     http.Header{"Authorization": []string{"Bearer token"}},
+		SessionID:  "session",
+	})
+	if addr, ok := GetRemoteAddr(ctx); !ok || addr != "127.0.0.1:1234" {
+		t.Errorf("Unexpected remote address %q", addr)
+	}
+	if got := GetHeader(ctx, "authorization"); got != "Bearer token" {
+		t.Errorf("Unexpected header %q", got)
+	}
+	if sessionID, ok := GetSessionID(ctx); !ok || sessionID != "session" {
+		t.Errorf("Unexpected session %q", sessionID)
+	}
+
+	ctx = ContextWithPrincipal(ctx, &testPrincipal{Name: "alice"})
+	if p, ok := GetPrincipal[*testPrincipal](ctx); !ok || p.Name != "alice" {
+		t.Errorf("Unexpected principal %+v", p)
+	}
+	if _, ok := GetPrincipal[string](ctx); ok {
+		t.Errorf("Expected no principal of another type")
+	}
+	if _, ok := GetPrincipal[string](context.Background()); ok {
+		t.Errorf("Expected no principal")
+	}
+}
@@ -0,0 +1,89 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicInfo describes a panic recovered while dispatching a single item of a batch.
+type PanicInfo struct {
+	// Value passed to panic.
+	Value any
+	// Stack of the panicking goroutine.
+	Stack []byte
+	// Method name, empty for responses.
+	Method      string
+	MessageType MessageType
+	// ID of the item, nil for notifications.
+	ID *RequestID
+}
+
+// PanicData is set as the Data of the InternalError returned for a panicking method,
+// if the handler was created with WithPanicStack.
+type PanicData struct {
+	Panic string `json:"panic"`
+	Stack string `json:"stack"`
+}
+
+// WithPanicStack attaches the panic value and stack trace to the InternalError returned
+// for a panicking method. Stacks can reveal internals, so only use this when clients are trusted.
+func WithPanicStack() HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.panicStack = true
+	}
+}
+
+// WithPanicHook calls hook for every panic recovered during dispatch, e.g. to log or report it.
+// The hook is called from the goroutine that recovered the panic.
+func WithPanicHook(hook func(ctx context.Context, info PanicInfo)) HandlerOption {
+	return func(h *BatchRequestHandler) {
+		h.panicHook = hook
+	}
+}
+
+// recoverItem recovers a panic raised while handling request, and sets response accordingly.
+// Methods get an InternalError response, other items get none.
+// It must be called directly by a deferred statement.
+func (brh *BatchRequestHandler) recoverItem(
+	ctx context.Context,
+	request UnionRequest,
+	msgType MessageType,
+	response **Response[json.RawMessage],
+) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	info := PanicInfo{
+		Value:       value,
+		Stack:       debug.Stack(),
+		MessageType: msgType,
+		ID:          request.ID,
+	}
+	if request.Method != nil {
+		info.Method = *request.Method
+	}
+	if brh.panicHook != nil {
+		brh.panicHook(ctx, info)
+	}
+
+	if msgType != MessageTypeMethod {
+		*response = nil
+		return
+	}
+	jerr := &JSONRPCError{
+		Code:    InternalError,
+		Message: GetDefaultErrorMessage(InternalError) + ": Panic while handling method: " + info.Method,
+	}
+	if brh.panicStack {
+		jerr.Data = PanicData{Panic: fmt.Sprint(value), Stack: string(info.Stack)}
+	}
+	*response = &Response[json.RawMessage]{
+		JSONRPC: JSONRPCVersion,
+		ID:      request.ID,
+		Error:   jerr,
+	}
+}
@@ -0,0 +1,112 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	panicking := &MethodHandler[AddParams, AddResult]{
+		Endpoint: func(ctx context.Context, params AddParams) (AddResult, error) {
+			panic("boom")
+		},
+	}
+	methodMap := map[string]IMethodHandler{
+		"add":   &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+		"panic": panicking,
+	}
+	notificationMap := map[string]INotificationHandler{
+		"panic": &NotificationHandler[PingParams]{
+			Endpoint: func(ctx context.Context, params PingParams) error {
+				panic("boom")
+			},
+		},
+	}
+	request := &BatchRequest{Body: &BatchItem[UnionRequest]{
+		IsBatch: true,
+		Items: []UnionRequest{
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("panic"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+				ID:      &RequestID{Value: 1},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("panic"),
+				Params:  json.RawMessage(`{"message":"hi"}`),
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("add"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+				ID:      &RequestID{Value: 2},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		opts      []HandlerOption
+		wantStack bool
+	}{
+		{name: "Sequential"},
+		{name: "Concurrent", opts: []HandlerOption{WithBatchConcurrency(3)}},
+		{name: "With stack", opts: []HandlerOption{WithPanicStack()}, wantStack: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var recovered []PanicInfo
+			opts := append([]HandlerOption{
+				WithMethodMap(methodMap),
+				WithNotificationMap(notificationMap),
+				WithPanicHook(func(ctx context.Context, info PanicInfo) {
+					mu.Lock()
+					defer mu.Unlock()
+					recovered = append(recovered, info)
+				}),
+			}, tc.opts...)
+			brh := NewBatchRequestHandler(opts...)
+
+			resp, err := brh.Handle(t.Context(), request)
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			if len(resp.Body.Items) != 2 {
+				t.Fatalf("Expected 2 responses, got %d", len(resp.Body.Items))
+			}
+
+			failed := resp.Body.Items[0]
+			if failed.Error == nil || failed.Error.Code != InternalError ||
+				!failed.ID.Equal(&RequestID{Value: 1}) {
+				t.Fatalf("Expected internal error for panicking method, got %#v", failed)
+			}
+			data, ok := failed.Error.Data.(PanicData)
+			if ok != tc.wantStack {
+				t.Fatalf("Expected stack in data: %v, got %#v", tc.wantStack, failed.Error.Data)
+			}
+			if ok && (data.Panic != "boom" || !strings.Contains(data.Stack, "recover_test.go")) {
+				t.Errorf("Unexpected panic data %#v", data)
+			}
+
+			if succeeded := resp.Body.Items[1]; succeeded.Error != nil ||
+				!jsonStringsEqual(string(succeeded.Result), `{"sum":3}`) {
+				t.Errorf("Expected rest of the batch to succeed, got %#v", succeeded)
+			}
+
+			if len(recovered) != 2 {
+				t.Fatalf("Expected hook to be called twice, got %d", len(recovered))
+			}
+			for _, info := range recovered {
+				if info.Value != "boom" || info.Method != "panic" || len(info.Stack) == 0 {
+					t.Errorf("Unexpected panic info %#v", info)
+				}
+			}
+		})
+	}
+}
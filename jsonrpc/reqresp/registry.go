@@ -0,0 +1,216 @@
+package reqresp
+
+import (
+	"maps"
+	"sync"
+)
+
+type HandlerKind string
+
+// Constants for the kinds of handlers held by a registry.
+const (
+	HandlerKindMethod       HandlerKind = "Method"
+	HandlerKindNotification HandlerKind = "Notification"
+	HandlerKindResponse     HandlerKind = "Response"
+)
+
+type RegistryOp string
+
+// Constants for the operations reported to registry subscribers.
+const (
+	RegistryOpRegistered   RegistryOp = "Registered"
+	RegistryOpUnregistered RegistryOp = "Unregistered"
+)
+
+// RegistryChange describes a single change to a registry.
+type RegistryChange struct {
+	Kind HandlerKind
+	Op   RegistryOp
+	Name string
+}
+
+// Registry is a concurrency safe store of method, notification and response handlers.
+// Handlers can be added and removed while a BatchRequestHandler is serving from it.
+//
+// Example:
+//
+//	registry := NewRegistry()
+//	registry.RegisterMethod("add", &MethodHandler[AddParams, int]{Endpoint: AddEndpoint})
+//	unsubscribe := registry.Subscribe(func(change RegistryChange) {
+//	    // E.g. send a list_changed notification.
+//	})
+//	defer unsubscribe()
+//	handler := NewBatchRequestHandler(WithRegistry(registry))
+type Registry struct {
+	mu            sync.RWMutex
+	methods       map[string]IMethodHandler
+	notifications map[string]INotificationHandler
+	responses     map[string]IResponseHandler
+
+	subscribers subscriberSet
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		methods:       make(map[string]IMethodHandler),
+		notifications: make(map[string]INotificationHandler),
+		responses:     make(map[string]IResponseHandler),
+	}
+}
+
+// RegisterMethod adds or replaces the handler for a method.
+func (r *Registry) RegisterMethod(name string, handler IMethodHandler) {
+	r.mu.Lock()
+	r.methods[name] = handler
+	r.mu.Unlock()
+	r.publish(RegistryChange{Kind: HandlerKindMethod, Op: RegistryOpRegistered, Name: name})
+}
+
+// RegisterNotification adds or replaces the handler for a notification.
+func (r *Registry) RegisterNotification(name string, handler INotificationHandler) {
+	r.mu.Lock()
+	r.notifications[name] = handler
+	r.mu.Unlock()
+	r.publish(RegistryChange{Kind: HandlerKindNotification, Op: RegistryOpRegistered, Name: name})
+}
+
+// RegisterResponse adds or replaces the handler for responses mapped to a method.
+func (r *Registry) RegisterResponse(name string, handler IResponseHandler) {
+	r.mu.Lock()
+	r.responses[name] = handler
+	r.mu.Unlock()
+	r.publish(RegistryChange{Kind: HandlerKindResponse, Op: RegistryOpRegistered, Name: name})
+}
+
+// UnregisterMethod removes the handler for a method. It reports whether a handler was removed.
+func (r *Registry) UnregisterMethod(name string) bool {
+	r.mu.Lock()
+	_, ok := r.methods[name]
+	delete(r.methods, name)
+	r.mu.Unlock()
+	if ok {
+		r.publish(RegistryChange{Kind: HandlerKindMethod, Op: RegistryOpUnregistered, Name: name})
+	}
+	return ok
+}
+
+// UnregisterNotification removes the handler for a notification. It reports whether a handler was removed.
+func (r *Registry) UnregisterNotification(name string) bool {
+	r.mu.Lock()
+	_, ok := r.notifications[name]
+	delete(r.notifications, name)
+	r.mu.Unlock()
+	if ok {
+		r.publish(
+			RegistryChange{Kind: HandlerKindNotification, Op: RegistryOpUnregistered, Name: name},
+		)
+	}
+	return ok
+}
+
+// UnregisterResponse removes the handler for responses mapped to a method.
+// It reports whether a handler was removed.
+func (r *Registry) UnregisterResponse(name string) bool {
+	r.mu.Lock()
+	_, ok := r.responses[name]
+	delete(r.responses, name)
+	r.mu.Unlock()
+	if ok {
+		r.publish(RegistryChange{Kind: HandlerKindResponse, Op: RegistryOpUnregistered, Name: name})
+	}
+	return ok
+}
+
+// LookupMethod returns the handler for a method.
+func (r *Registry) LookupMethod(name string) (IMethodHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.methods[name]
+	return handler, ok
+}
+
+// LookupNotification returns the handler for a notification.
+func (r *Registry) LookupNotification(name string) (INotificationHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.notifications[name]
+	return handler, ok
+}
+
+// LookupResponse returns the handler for responses mapped to a method.
+func (r *Registry) LookupResponse(name string) (IResponseHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.responses[name]
+	return handler, ok
+}
+
+// MethodMap returns a snapshot of the registered method handlers.
+func (r *Registry) MethodMap() map[string]IMethodHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.methods)
+}
+
+// NotificationMap returns a snapshot of the registered notification handlers.
+func (r *Registry) NotificationMap() map[string]INotificationHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.notifications)
+}
+
+// ResponseMap returns a snapshot of the registered response handlers.
+func (r *Registry) ResponseMap() map[string]IResponseHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.responses)
+}
+
+// Subscribe registers fn to be called after every change to the registry.
+// Subscribers are called synchronously from the goroutine making the change.
+// The returned function removes the subscription.
+func (r *Registry) Subscribe(fn func(RegistryChange)) (unsubscribe func()) {
+	return r.subscribers.subscribe(fn)
+}
+
+func (r *Registry) publish(change RegistryChange) {
+	r.subscribers.publish(change)
+}
+
+// subscriberSet holds the subscribers to the changes of a registry or a router.
+type subscriberSet struct {
+	mu     sync.RWMutex
+	fns    map[uint64]func(RegistryChange)
+	nextID uint64
+}
+
+func (s *subscriberSet) subscribe(fn func(RegistryChange)) (unsubscribe func()) {
+	s.mu.Lock()
+	if s.fns == nil {
+		s.fns = make(map[uint64]func(RegistryChange))
+	}
+	id := s.nextID
+	s.nextID++
+	s.fns[id] = fn
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.fns, id)
+		s.mu.Unlock()
+	}
+}
+
+func (s *subscriberSet) publish(change RegistryChange) {
+	s.mu.RLock()
+	subscribers := make([]func(RegistryChange), 0, len(s.fns))
+	for _, fn := range s.fns {
+		subscribers = append(subscribers, fn)
+	}
+	s.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(change)
+	}
+}
@@ -0,0 +1,117 @@
+package reqresp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRegistryRuntimeChanges(t *testing.T) {
+	registry := NewRegistry()
+	var changes []RegistryChange
+	unsubscribe := registry.Subscribe(func(change RegistryChange) {
+		changes = append(changes, change)
+	})
+
+	brh := NewBatchRequestHandler(WithRegistry(registry))
+	call := func() *Response[json.RawMessage] {
+		resp, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+			Items: []UnionRequest{{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("add"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+				ID:      &RequestID{Value: 1},
+			}},
+		}})
+		if err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+		return &resp.Body.Items[0]
+	}
+
+	if resp := call(); resp.Error == nil || resp.Error.Code != MethodNotFoundError {
+		t.Fatalf("Expected method not found before registration, got %#v", resp)
+	}
+
+	registry.RegisterMethod("add", &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint})
+	if resp := call(); resp.Error != nil || !jsonStringsEqual(string(resp.Result), `{"sum":3}`) {
+		t.Fatalf("Expected registered method to be served, got %#v", resp)
+	}
+
+	if !registry.UnregisterMethod("add") {
+		t.Errorf("Expected unregister to report removal")
+	}
+	if registry.UnregisterMethod("add") {
+		t.Errorf("Expected second unregister to report no removal")
+	}
+	if resp := call(); resp.Error == nil || resp.Error.Code != MethodNotFoundError {
+		t.Fatalf("Expected method not found after unregistration, got %#v", resp)
+	}
+
+	unsubscribe()
+	registry.RegisterNotification("ping", &NotificationHandler[PingParams]{Endpoint: PingEndpoint})
+
+	want := []RegistryChange{
+		{Kind: HandlerKindMethod, Op: RegistryOpRegistered, Name: "add"},
+		{Kind: HandlerKindMethod, Op: RegistryOpUnregistered, Name: "add"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Unexpected changes. Got: %#v, Want: %#v", changes, want)
+	}
+	if _, ok := registry.NotificationMap()["ping"]; !ok {
+		t.Errorf("Expected notification snapshot to contain ping")
+	}
+}
+
+func TestRegistryMapOptions(t *testing.T) {
+	registry := NewRegistry()
+	brh := NewBatchRequestHandler(
+		WithRegistry(registry),
+		WithMethodMap(map[string]IMethodHandler{
+			"add": &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint},
+		}),
+	)
+	if brh.Registry() != registry {
+		t.Fatalf("Expected handler to use the given registry")
+	}
+	if _, ok := registry.LookupMethod("add"); !ok {
+		t.Errorf("Expected method map entries to be added to the registry")
+	}
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	registry := NewRegistry()
+	brh := NewBatchRequestHandler(WithRegistry(registry))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		name := fmt.Sprintf("add%d", i)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				registry.RegisterMethod(name, &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint})
+				registry.UnregisterMethod(name)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+					Items: []UnionRequest{{
+						JSONRPC: JSONRPCVersion,
+						Method:  stringToPointer(name),
+						Params:  json.RawMessage(`{"a":1,"b":2}`),
+						ID:      &RequestID{Value: 1},
+					}},
+				}})
+				if err != nil {
+					t.Errorf("Handle returned error: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,246 @@
+package reqresp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// routerMount is a registry mounted under a prefix.
+type routerMount struct {
+	prefix               string
+	registry             *Registry
+	methodFallback       IMethodHandler
+	notificationFallback INotificationHandler
+	// unsubscribe stops forwarding the changes of the registry.
+	unsubscribe func()
+}
+
+// Router dispatches to registries mounted under method name prefixes.
+//
+// A name is resolved by the mount with the longest matching prefix, with the prefix stripped.
+// If that registry has no handler for the name, the fallback of the mount is used if set,
+// otherwise the mount with the next longest matching prefix is tried.
+//
+// Example:
+//
+//	tools := NewRegistry()
+//	tools.RegisterMethod("list", listToolsHandler)
+//	tools.RegisterMethod("call", callToolHandler)
+//
+//	router := NewRouter()
+//	_ = router.Mount("tools/", tools)
+//	handler := NewBatchRequestHandler(WithRouter(router))
+//	// "tools/list" is served by listToolsHandler.
+type Router struct {
+	mu sync.RWMutex
+	// Mounts, ordered by descending prefix length, then by prefix.
+	mounts []*routerMount
+
+	subscribers subscriberSet
+}
+
+// NewRouter creates a router without mounts.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Mount serves the handlers of registry under prefix. The prefix "" mounts at the root.
+// Each prefix can be mounted only once.
+func (r *Router) Mount(prefix string, registry *Registry) error {
+	if registry == nil {
+		return fmt.Errorf("cannot mount nil registry at %q", prefix)
+	}
+	r.mu.Lock()
+	if r.find(prefix) != nil {
+		r.mu.Unlock()
+		return fmt.Errorf("prefix %q is already mounted", prefix)
+	}
+	r.mounts = append(r.mounts, &routerMount{
+		prefix:   prefix,
+		registry: registry,
+		unsubscribe: registry.Subscribe(func(change RegistryChange) {
+			change.Name = prefix + change.Name
+			r.subscribers.publish(change)
+		}),
+	})
+	slices.SortFunc(r.mounts, func(a, b *routerMount) int {
+		if n := len(b.prefix) - len(a.prefix); n != 0 {
+			return n
+		}
+		return strings.Compare(a.prefix, b.prefix)
+	})
+	r.mu.Unlock()
+
+	r.publishHandlers(prefix, registry, RegistryOpRegistered)
+	return nil
+}
+
+// Unmount removes the registry mounted at prefix. It reports whether a mount was removed.
+func (r *Router) Unmount(prefix string) bool {
+	r.mu.Lock()
+	m := r.find(prefix)
+	if m == nil {
+		r.mu.Unlock()
+		return false
+	}
+	m.unsubscribe()
+	r.mounts = slices.DeleteFunc(r.mounts, func(other *routerMount) bool {
+		return other == m
+	})
+	r.mu.Unlock()
+
+	r.publishHandlers(prefix, m.registry, RegistryOpUnregistered)
+	return true
+}
+
+// Subscribe registers fn to be called after every change to the handlers of the router: the changes
+// of the mounted registries, with full method names, and a change per handler when a registry is
+// mounted or unmounted. Fallbacks are not reported.
+// Subscribers are called synchronously from the goroutine making the change.
+// The returned function removes the subscription.
+func (r *Router) Subscribe(fn func(RegistryChange)) (unsubscribe func()) {
+	return r.subscribers.subscribe(fn)
+}
+
+// publishHandlers reports op for each handler of the registry mounted at prefix.
+func (r *Router) publishHandlers(prefix string, registry *Registry, op RegistryOp) {
+	for name := range registry.MethodMap() {
+		r.subscribers.publish(RegistryChange{Kind: HandlerKindMethod, Op: op, Name: prefix + name})
+	}
+	for name := range registry.NotificationMap() {
+		r.subscribers.publish(RegistryChange{Kind: HandlerKindNotification, Op: op, Name: prefix + name})
+	}
+	for name := range registry.ResponseMap() {
+		r.subscribers.publish(RegistryChange{Kind: HandlerKindResponse, Op: op, Name: prefix + name})
+	}
+}
+
+// SetMethodFallback sets the handler for methods under prefix that the mounted registry
+// does not handle. A nil handler removes the fallback.
+func (r *Router) SetMethodFallback(prefix string, handler IMethodHandler) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.find(prefix)
+	if m == nil {
+		return fmt.Errorf("prefix %q is not mounted", prefix)
+	}
+	m.methodFallback = handler
+	return nil
+}
+
+// SetNotificationFallback sets the handler for notifications under prefix that the mounted
+// registry does not handle. A nil handler removes the fallback.
+func (r *Router) SetNotificationFallback(prefix string, handler INotificationHandler) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.find(prefix)
+	if m == nil {
+		return fmt.Errorf("prefix %q is not mounted", prefix)
+	}
+	m.notificationFallback = handler
+	return nil
+}
+
+// Prefixes returns the mounted prefixes, longest first, in the order names are resolved.
+func (r *Router) Prefixes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	prefixes := make([]string, 0, len(r.mounts))
+	for _, m := range r.mounts {
+		prefixes = append(prefixes, m.prefix)
+	}
+	return prefixes
+}
+
+// LookupMethod returns the handler for a method.
+func (r *Router) LookupMethod(name string) (IMethodHandler, bool) {
+	return routerLookup(r, name, (*Registry).LookupMethod, func(m *routerMount) IMethodHandler {
+		return m.methodFallback
+	})
+}
+
+// LookupNotification returns the handler for a notification.
+func (r *Router) LookupNotification(name string) (INotificationHandler, bool) {
+	return routerLookup(
+		r,
+		name,
+		(*Registry).LookupNotification,
+		func(m *routerMount) INotificationHandler {
+			return m.notificationFallback
+		},
+	)
+}
+
+// LookupResponse returns the handler for responses mapped to a method.
+// Response handlers have no fallbacks.
+func (r *Router) LookupResponse(name string) (IResponseHandler, bool) {
+	return routerLookup(r, name, (*Registry).LookupResponse, func(*routerMount) IResponseHandler {
+		return nil
+	})
+}
+
+// MethodMap returns a snapshot of the method handlers served by the router, by full method name.
+// Fallbacks are not included.
+func (r *Router) MethodMap() map[string]IMethodHandler {
+	return routerSnapshot(r, (*Registry).MethodMap)
+}
+
+// NotificationMap returns a snapshot of the notification handlers served by the router,
+// by full method name. Fallbacks are not included.
+func (r *Router) NotificationMap() map[string]INotificationHandler {
+	return routerSnapshot(r, (*Registry).NotificationMap)
+}
+
+// ResponseMap returns a snapshot of the response handlers served by the router, by full method name.
+func (r *Router) ResponseMap() map[string]IResponseHandler {
+	return routerSnapshot(r, (*Registry).ResponseMap)
+}
+
+// find returns the mount at prefix, nil if there is none. The caller must hold the lock.
+func (r *Router) find(prefix string) *routerMount {
+	for _, m := range r.mounts {
+		if m.prefix == prefix {
+			return m
+		}
+	}
+	return nil
+}
+
+func routerLookup[H any](
+	r *Router,
+	name string,
+	lookup func(*Registry, string) (H, bool),
+	fallback func(*routerMount) H,
+) (H, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.mounts {
+		rest, ok := strings.CutPrefix(name, m.prefix)
+		if !ok {
+			continue
+		}
+		if handler, ok := lookup(m.registry, rest); ok {
+			return handler, true
+		}
+		if handler := fallback(m); any(handler) != nil {
+			return handler, true
+		}
+	}
+	var zero H
+	return zero, false
+}
+
+func routerSnapshot[H any](r *Router, snapshot func(*Registry) map[string]H) map[string]H {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handlers := make(map[string]H)
+	// Longer prefixes take precedence, so they are added last.
+	for _, m := range slices.Backward(r.mounts) {
+		for name, handler := range snapshot(m.registry) {
+			handlers[m.prefix+name] = handler
+		}
+	}
+	return handlers
+}
@@ -0,0 +1,157 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	tools := NewRegistry()
+	tools.RegisterMethod("add", &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint})
+	admin := NewRegistry()
+	admin.RegisterMethod("concat", &MethodHandler[ConcatParams, string]{Endpoint: ConcatEndpoint})
+	root := NewRegistry()
+	root.RegisterMethod("other/add", &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint})
+
+	var notified []string
+	notifications := NewRegistry()
+	notifications.RegisterNotification("ping", &NotificationHandler[PingParams]{
+		Endpoint: func(ctx context.Context, params PingParams) error {
+			method, _ := GetMethodName(ctx)
+			notified = append(notified, method)
+			return nil
+		},
+	})
+
+	router := NewRouter()
+	for prefix, registry := range map[string]*Registry{
+		"tools/":         tools,
+		"tools/admin/":   admin,
+		"":               root,
+		"notifications/": notifications,
+	} {
+		if err := router.Mount(prefix, registry); err != nil {
+			t.Fatalf("Mount(%q) returned error: %v", prefix, err)
+		}
+	}
+	if err := router.Mount("tools/", NewRegistry()); err == nil {
+		t.Errorf("Expected mounting a prefix twice to fail")
+	}
+	err := router.SetMethodFallback("tools/", &MethodHandler[json.RawMessage, string]{
+		Endpoint: func(ctx context.Context, _ json.RawMessage) (string, error) {
+			method, _ := GetMethodName(ctx)
+			return "fallback:" + method, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetMethodFallback returned error: %v", err)
+	}
+	if err := router.SetMethodFallback("prompts/", nil); err == nil {
+		t.Errorf("Expected setting a fallback on an unmounted prefix to fail")
+	}
+
+	brh := NewBatchRequestHandler(WithRouter(router))
+	call := func(method, params string) Response[json.RawMessage] {
+		resp, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+			Items: []UnionRequest{{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer(method),
+				Params:  json.RawMessage(params),
+				ID:      &RequestID{Value: 1},
+			}},
+		}})
+		if err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+		return resp.Body.Items[0]
+	}
+
+	tests := []struct {
+		method     string
+		params     string
+		wantResult string
+		wantCode   JSONRPCErrorCode
+	}{
+		{method: "tools/add", params: `{"a":1,"b":2}`, wantResult: `{"sum":3}`},
+		{method: "tools/admin/concat", params: `{"s1":"a","s2":"b"}`, wantResult: `"ab"`},
+		{method: "other/add", params: `{"a":2,"b":2}`, wantResult: `{"sum":4}`},
+		{method: "tools/admin/add", params: `{}`, wantResult: `"fallback:tools/admin/add"`},
+		{method: "tools/unknown", params: `{}`, wantResult: `"fallback:tools/unknown"`},
+		{method: "add", params: `{"a":1,"b":2}`, wantCode: MethodNotFoundError},
+		{method: "prompts/list", params: `{}`, wantCode: MethodNotFoundError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method, func(t *testing.T) {
+			resp := call(tc.method, tc.params)
+			if tc.wantCode != 0 {
+				if resp.Error == nil || resp.Error.Code != tc.wantCode {
+					t.Fatalf("Expected error code %d, got %#v", tc.wantCode, resp)
+				}
+				return
+			}
+			if resp.Error != nil || !jsonStringsEqual(string(resp.Result), tc.wantResult) {
+				t.Errorf("Expected result %s, got %#v", tc.wantResult, resp)
+			}
+		})
+	}
+
+	_, err = brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+		Items: []UnionRequest{{
+			JSONRPC: JSONRPCVersion,
+			Method:  stringToPointer("notifications/ping"),
+			Params:  json.RawMessage(`{"message":"hi"}`),
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if want := []string{"notifications/ping"}; !reflect.DeepEqual(notified, want) {
+		t.Errorf("Expected notification with full method name, got %q", notified)
+	}
+
+	methods := slices.Sorted(maps.Keys(router.MethodMap()))
+	if want := []string{"other/add", "tools/add", "tools/admin/concat"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("Unexpected method listing. Got: %q, Want: %q", methods, want)
+	}
+
+	// The handler lists the methods of its registry and of the router.
+	brh.Registry().RegisterMethod("own", &MethodHandler[AddParams, AddResult]{Endpoint: AddEndpoint})
+	methods = slices.Sorted(maps.Keys(brh.MethodMap()))
+	if want := []string{"other/add", "own", "tools/add", "tools/admin/concat"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("Unexpected handler method listing. Got: %q, Want: %q", methods, want)
+	}
+
+	var changes []RegistryChange
+	unsubscribe := brh.Subscribe(func(change RegistryChange) {
+		changes = append(changes, change)
+	})
+	admin.RegisterMethod("echo", &MethodHandler[ConcatParams, string]{Endpoint: ConcatEndpoint})
+	if !router.Unmount("tools/admin/") {
+		t.Fatalf("Expected unmount to report removal")
+	}
+	admin.UnregisterMethod("echo")
+	unsubscribe()
+	slices.SortFunc(changes[1:], func(a, b RegistryChange) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	wantChanges := []RegistryChange{
+		{Kind: HandlerKindMethod, Op: RegistryOpRegistered, Name: "tools/admin/echo"},
+		{Kind: HandlerKindMethod, Op: RegistryOpUnregistered, Name: "tools/admin/concat"},
+		{Kind: HandlerKindMethod, Op: RegistryOpUnregistered, Name: "tools/admin/echo"},
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("Unexpected changes. Got: %+v, Want: %+v", changes, wantChanges)
+	}
+	if resp := call("tools/admin/concat", `{"s1":"a","s2":"b"}`); resp.Error != nil ||
+		!jsonStringsEqual(string(resp.Result), `"fallback:tools/admin/concat"`) {
+		t.Errorf("Expected unmounted prefix to be served by the fallback of tools/, got %#v", resp)
+	}
+	if want := []string{"notifications/", "tools/", ""}; !reflect.DeepEqual(router.Prefixes(), want) {
+		t.Errorf("Unexpected prefixes. Got: %q, Want: %q", router.Prefixes(), want)
+	}
+}
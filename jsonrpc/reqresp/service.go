@@ -0,0 +1,240 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// NameMapper maps a service name and one of its method names to a JSON-RPC method name.
+type NameMapper func(serviceName, methodName string) string
+
+// DefaultNameMapper maps Service.Method to "Service.Method", as net/rpc does.
+func DefaultNameMapper(serviceName, methodName string) string {
+	return serviceName + "." + methodName
+}
+
+// SlashNameMapper maps Service.Method to "service/method".
+func SlashNameMapper(serviceName, methodName string) string {
+	return lowerFirst(serviceName) + "/" + lowerFirst(methodName)
+}
+
+// MethodNameMapper maps Service.Method to "method", ignoring the service name.
+func MethodNameMapper(serviceName, methodName string) string {
+	return lowerFirst(methodName)
+}
+
+type serviceConfig struct {
+	name           string
+	nameMapper     NameMapper
+	validateParams bool
+}
+
+// ServiceOption configures how the methods of a service are registered.
+type ServiceOption func(*serviceConfig)
+
+// WithServiceName overrides the service name, which defaults to the name of the struct type.
+func WithServiceName(name string) ServiceOption {
+	return func(c *serviceConfig) {
+		c.name = name
+	}
+}
+
+// WithNameMapper sets how method names are built. The default is DefaultNameMapper.
+func WithNameMapper(mapper NameMapper) ServiceOption {
+	return func(c *serviceConfig) {
+		c.nameMapper = mapper
+	}
+}
+
+// WithParamsValidation validates params against the schema of the params type, like
+// MethodHandler.ValidateParams.
+func WithParamsValidation() ServiceOption {
+	return func(c *serviceConfig) {
+		c.validateParams = true
+	}
+}
+
+// NewServiceHandlers builds method and notification handlers from the exported methods of service.
+//
+// Methods with the signature
+//
+//	func(ctx context.Context, params I) (O, error)
+//
+// become method handlers, and methods with the signature
+//
+//	func(ctx context.Context, params I) error
+//
+// become notification handlers. Other methods are skipped.
+// The generated handlers report I and O from GetTypes, so they can be documented like MethodHandler.
+//
+// Example:
+//
+//	type MathService struct{}
+//
+//	func (s *MathService) Add(ctx context.Context, params AddParams) (int, error) { ... }
+//
+//	methodMap, notificationMap, err := NewServiceHandlers(&MathService{}, WithNameMapper(SlashNameMapper))
+//	// methodMap contains "mathService/add".
+func NewServiceHandlers(
+	service any,
+	opts ...ServiceOption,
+) (map[string]IMethodHandler, map[string]INotificationHandler, error) {
+	if service == nil {
+		return nil, nil, errors.New("service cannot be nil")
+	}
+	serviceValue := reflect.ValueOf(service)
+	serviceType := serviceValue.Type()
+
+	cfg := serviceConfig{
+		name:       reflect.Indirect(serviceValue).Type().Name(),
+		nameMapper: DefaultNameMapper,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.name == "" {
+		return nil, nil, fmt.Errorf("service name cannot be derived for type %s", serviceType)
+	}
+
+	methodMap := make(map[string]IMethodHandler)
+	notificationMap := make(map[string]INotificationHandler)
+	for i := range serviceType.NumMethod() {
+		method := serviceType.Method(i)
+		if !method.IsExported() {
+			continue
+		}
+		fn := serviceValue.Method(i)
+		fnType := fn.Type()
+		if fnType.NumIn() != 2 || fnType.In(0) != contextType {
+			continue
+		}
+		name := cfg.nameMapper(cfg.name, method.Name)
+		var validator *ParamsValidator
+		if cfg.validateParams {
+			validator = NewParamsValidator(fnType.In(1))
+		}
+
+		switch {
+		case fnType.NumOut() == 2 && fnType.Out(1) == errorType:
+			methodMap[name] = &serviceMethodHandler{
+				fn:        fn,
+				iType:     fnType.In(1),
+				oType:     fnType.Out(0),
+				validator: validator,
+			}
+		case fnType.NumOut() == 1 && fnType.Out(0) == errorType:
+			notificationMap[name] = &serviceNotificationHandler{
+				fn:        fn,
+				iType:     fnType.In(1),
+				validator: validator,
+			}
+		}
+	}
+
+	if len(methodMap) == 0 && len(notificationMap) == 0 {
+		return nil, nil, fmt.Errorf("type %s has no exported methods of suitable type", serviceType)
+	}
+	return methodMap, notificationMap, nil
+}
+
+// RegisterService adds the handlers built by NewServiceHandlers to registry.
+func RegisterService(registry *Registry, service any, opts ...ServiceOption) error {
+	methodMap, notificationMap, err := NewServiceHandlers(service, opts...)
+	if err != nil {
+		return err
+	}
+	for name, handler := range methodMap {
+		registry.RegisterMethod(name, handler)
+	}
+	for name, handler := range notificationMap {
+		registry.RegisterNotification(name, handler)
+	}
+	return nil
+}
+
+// serviceMethodHandler is a method handler calling a service method through reflection.
+type serviceMethodHandler struct {
+	fn    reflect.Value
+	iType reflect.Type
+	oType reflect.Type
+	// Validator of the params, nil if they are not validated.
+	validator *ParamsValidator
+}
+
+// Handle processes a request expecting a response.
+func (s *serviceMethodHandler) Handle(
+	ctx context.Context,
+	req Request[json.RawMessage],
+) Response[json.RawMessage] {
+	if s.validator != nil {
+		if err := s.validator.Validate(req.Params); err != nil {
+			return invalidParamsResponse(req, err)
+		}
+	}
+	codec := GetCodec(ctx)
+	params := reflect.New(s.iType)
+	if err := unmarshalInto(codec, req.Params, params.Interface()); err != nil {
+		// Return InvalidParamsError.
+		return invalidParamsResponse(req, err)
+	}
+
+	out := s.fn.Call([]reflect.Value{reflect.ValueOf(ctx), params.Elem()})
+	err, _ := out[1].Interface().(error)
+	return methodResponse(ctx, codec, req, out[0].Interface(), err)
+}
+
+// GetTypes returns the reflect.Type of the input and output types.
+func (s *serviceMethodHandler) GetTypes() (iType, oType reflect.Type) {
+	return s.iType, s.oType
+}
+
+// serviceNotificationHandler is a notification handler calling a service method through reflection.
+type serviceNotificationHandler struct {
+	fn    reflect.Value
+	iType reflect.Type
+	// Validator of the params, nil if they are not validated.
+	validator *ParamsValidator
+}
+
+// Handle processes a notification (no response expected).
+func (s *serviceNotificationHandler) Handle(
+	ctx context.Context,
+	req Notification[json.RawMessage],
+) error {
+	if s.validator != nil {
+		if err := s.validator.Validate(req.Params); err != nil {
+			return err
+		}
+	}
+	params := reflect.New(s.iType)
+	if err := unmarshalInto(GetCodec(ctx), req.Params, params.Interface()); err != nil {
+		return err
+	}
+
+	out := s.fn.Call([]reflect.Value{reflect.ValueOf(ctx), params.Elem()})
+	err, _ := out[0].Interface().(error)
+	return err
+}
+
+// GetTypes returns the reflect.Type of the input.
+func (s *serviceNotificationHandler) GetTypes() reflect.Type {
+	return s.iType
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
@@ -0,0 +1,128 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type mathService struct {
+	notified []string
+}
+
+func (s *mathService) Add(ctx context.Context, params AddParams) (AddResult, error) {
+	return AddResult{Sum: params.A + params.B}, nil
+}
+
+func (s *mathService) Fail(ctx context.Context, params AddParams) (*AddResult, error) {
+	return nil, errors.New("failed")
+}
+
+func (s *mathService) Notify(ctx context.Context, params NotifyParams) error {
+	s.notified = append(s.notified, params.Message)
+	return nil
+}
+
+// Helper has no suitable signature and must be skipped.
+func (s *mathService) Helper(a, b int) int {
+	return a + b
+}
+
+func TestNewServiceHandlers(t *testing.T) {
+	svc := &mathService{}
+	methodMap, notificationMap, err := NewServiceHandlers(svc, WithNameMapper(SlashNameMapper))
+	if err != nil {
+		t.Fatalf("NewServiceHandlers returned error: %v", err)
+	}
+
+	gotMethods := make([]string, 0, len(methodMap))
+	for name := range methodMap {
+		gotMethods = append(gotMethods, name)
+	}
+	if len(methodMap) != 2 || methodMap["mathService/add"] == nil || methodMap["mathService/fail"] == nil {
+		t.Fatalf("Unexpected methods: %v", gotMethods)
+	}
+	if len(notificationMap) != 1 || notificationMap["mathService/notify"] == nil {
+		t.Fatalf("Unexpected notifications: %v", notificationMap)
+	}
+
+	iType, oType := methodMap["mathService/add"].GetTypes()
+	if iType != reflect.TypeOf(AddParams{}) || oType != reflect.TypeOf(AddResult{}) {
+		t.Errorf("Unexpected types for add: %v, %v", iType, oType)
+	}
+	if nType := notificationMap["mathService/notify"].GetTypes(); nType != reflect.TypeOf(NotifyParams{}) {
+		t.Errorf("Unexpected type for notify: %v", nType)
+	}
+
+	brh := NewBatchRequestHandler(WithMethodMap(methodMap), WithNotificationMap(notificationMap))
+	resp, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+		IsBatch: true,
+		Items: []UnionRequest{
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("mathService/add"),
+				Params:  json.RawMessage(`{"a":1,"b":2}`),
+				ID:      &RequestID{Value: 1},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("mathService/add"),
+				Params:  json.RawMessage(`{"a":"one"}`),
+				ID:      &RequestID{Value: 2},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("mathService/fail"),
+				ID:      &RequestID{Value: 3},
+			},
+			{
+				JSONRPC: JSONRPCVersion,
+				Method:  stringToPointer("mathService/notify"),
+				Params:  json.RawMessage(`{"message":"hello"}`),
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	items := resp.Body.Items
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 responses, got %d", len(items))
+	}
+	if items[0].Error != nil || !jsonStringsEqual(string(items[0].Result), `{"sum":3}`) {
+		t.Errorf("Unexpected add response: %#v", items[0])
+	}
+	if items[1].Error == nil || items[1].Error.Code != InvalidParamsError {
+		t.Errorf("Expected invalid params error, got %#v", items[1])
+	}
+	if items[2].Error == nil || items[2].Error.Code != InternalError {
+		t.Errorf("Expected internal error, got %#v", items[2])
+	}
+	if !reflect.DeepEqual(svc.notified, []string{"hello"}) {
+		t.Errorf("Expected notification to be delivered, got %v", svc.notified)
+	}
+}
+
+func TestNewServiceHandlersNaming(t *testing.T) {
+	methodMap, _, err := NewServiceHandlers(&mathService{}, WithServiceName("Calc"))
+	if err != nil {
+		t.Fatalf("NewServiceHandlers returned error: %v", err)
+	}
+	if methodMap["Calc.Add"] == nil {
+		t.Errorf("Expected default name mapping to produce Calc.Add, got %v", methodMap)
+	}
+
+	registry := NewRegistry()
+	if err := RegisterService(registry, &mathService{}, WithNameMapper(MethodNameMapper)); err != nil {
+		t.Fatalf("RegisterService returned error: %v", err)
+	}
+	if _, ok := registry.LookupMethod("add"); !ok {
+		t.Errorf("Expected add to be registered")
+	}
+
+	if _, _, err := NewServiceHandlers(struct{}{}); err == nil {
+		t.Errorf("Expected error for service without suitable methods")
+	}
+}
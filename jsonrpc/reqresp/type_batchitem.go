@@ -32,7 +32,7 @@ func unmarshalBatchItem[T any](data []byte, isBatch *bool, items *[]T) error {
 	data = bytes.TrimSpace(data)
 	// Try to unmarshal into []json.RawMessage to detect if it's a batch.
 	var rawMessages []json.RawMessage
-	if err := json.Unmarshal(data, &rawMessages); err == nil {
+	if err := DefaultCodec().Unmarshal(data, &rawMessages); err == nil {
 		// Data is a batch.
 		*isBatch = true
 		// Process each message in the batch, empty slice input is also ok and valid.
@@ -42,7 +42,7 @@ func unmarshalBatchItem[T any](data []byte, isBatch *bool, items *[]T) error {
 				return err
 			}
 			var item T
-			if err := json.Unmarshal(msg, &item); err != nil {
+			if err := DefaultCodec().Unmarshal(msg, &item); err != nil {
 				return &JSONRPCError{
 					Code: ParseError,
 					Message: GetDefaultErrorMessage(
@@ -54,7 +54,7 @@ func unmarshalBatchItem[T any](data []byte, isBatch *bool, items *[]T) error {
 		}
 	} else {
 		var item T
-		if err := json.Unmarshal(data, &item); err != nil {
+		if err := DefaultCodec().Unmarshal(data, &item); err != nil {
 			return &JSONRPCError{
 				Code:    ParseError,
 				Message: GetDefaultErrorMessage(ParseError) + ": Failed to unmarshal single item: " + err.Error(),
@@ -69,12 +69,12 @@ func unmarshalBatchItem[T any](data []byte, isBatch *bool, items *[]T) error {
 // Generic function to marshal BatchItem structures.
 func marshalBatchItem[T any](isBatch bool, items []T) ([]byte, error) {
 	if isBatch {
-		return json.Marshal(items)
+		return DefaultCodec().Marshal(items)
 	}
 	if len(items) > 0 {
-		return json.Marshal(items[0])
+		return DefaultCodec().Marshal(items[0])
 	}
-	return json.Marshal(nil)
+	return DefaultCodec().Marshal(nil)
 }
 
 // BatchItem is a generic struct to detect and handle batch Items of any type.
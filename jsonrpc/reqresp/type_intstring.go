@@ -1,52 +1,91 @@
 package reqresp
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"strconv"
 )
 
+// IntString holds a JSON number or string, as used for request IDs.
+//
+// Value is a string for JSON strings, an int for integers that fit in an int, and a json.Number
+// for all other numbers, e.g. 1.0, 1e3 or integers overflowing int.
+// A decoded IntString marshals back to the exact token it was decoded from, as long as Value is
+// not changed, so that request IDs are echoed byte for byte.
 type IntString struct {
 	Value any
+
+	// Token Value was decoded from, and the Value it decoded to.
+	raw      json.RawMessage
+	rawValue any
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
 func (is *IntString) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
 	if string(data) == "null" {
 		// If the input is "null", return an error for non-pointer types
 		// (UnmarshalJSON is called only for non-pointer types in this case).
 		return errors.New("IntString cannot be null")
 	}
 
-	// Try to unmarshal data into an int.
-	var intValue int
-	if err := json.Unmarshal(data, &intValue); err == nil {
-		is.Value = intValue
-		return nil
-	}
-
-	// Try to unmarshal data into a string.
-	var strValue string
-	if err := json.Unmarshal(data, &strValue); err == nil {
+	switch {
+	case len(data) > 0 && data[0] == '"':
+		// Try to unmarshal data into a string.
+		var strValue string
+		if err := DefaultCodec().Unmarshal(data, &strValue); err != nil {
+			return err
+		}
 		is.Value = strValue
-		return nil
+
+	case isJSONNumber(data):
+		if intValue, err := strconv.Atoi(string(data)); err == nil {
+			is.Value = intValue
+		} else {
+			is.Value = json.Number(data)
+		}
+
+	default:
+		// If neither number nor string, return an error.
+		return errors.New("IntString must be a string or a number")
 	}
 
-	// If neither int nor string, return an error.
-	return errors.New("IntString must be a string or an integer")
+	is.raw = bytes.Clone(data)
+	is.rawValue = is.Value
+	return nil
 }
 
 // MarshalJSON implements the json.Marshaler interface.
 func (is IntString) MarshalJSON() ([]byte, error) {
+	if is.raw != nil && is.unchanged() {
+		return is.raw, nil
+	}
 	switch v := is.Value.(type) {
 	case int:
-		return json.Marshal(v)
+		return DefaultCodec().Marshal(v)
+	case json.Number:
+		if !isJSONNumber([]byte(v)) {
+			return nil, errors.New("IntString contains an invalid number")
+		}
+		return []byte(v), nil
 	case string:
-		return json.Marshal(v)
+		return DefaultCodec().Marshal(v)
 	default:
 		return nil, errors.New("IntString contains unsupported type")
 	}
 }
 
+// unchanged reports whether Value is still the value decoded from raw.
+func (is IntString) unchanged() bool {
+	switch v := is.Value.(type) {
+	case int, json.Number, string:
+		return v == is.rawValue
+	default:
+		return false
+	}
+}
+
 // Helper methods.
 func (is IntString) IsInt() bool {
 	_, ok := is.Value.(int)
@@ -58,6 +97,12 @@ func (is IntString) IsString() bool {
 	return ok
 }
 
+// IsNumber reports whether the value is a number, i.e. an int or a json.Number.
+func (is IntString) IsNumber() bool {
+	_, ok := is.NumberValue()
+	return ok
+}
+
 func (is IntString) IntValue() (int, bool) {
 	v, ok := is.Value.(int)
 	return v, ok
@@ -68,6 +113,32 @@ func (is IntString) StringValue() (string, bool) {
 	return v, ok
 }
 
+// NumberValue returns the value as a json.Number, if it is a number.
+func (is IntString) NumberValue() (json.Number, bool) {
+	switch v := is.Value.(type) {
+	case int:
+		return json.Number(strconv.Itoa(v)), true
+	case json.Number:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+// Key returns a string identifying the value, for use as a map key.
+// Numbers and strings never share a key, so 1 and "1" are different keys.
+func (is IntString) Key() string {
+	if s, ok := is.StringValue(); ok {
+		return "s:" + s
+	}
+	if n, ok := is.NumberValue(); ok {
+		return "n:" + string(n)
+	}
+	return ""
+}
+
+// Equal reports whether both values are the same string, or numbers written the same way.
+// A number and a string are never equal, even if they read the same, e.g. 1 and "1".
 func (is *IntString) Equal(other *IntString) bool {
 	// Handle nil cases.
 	if is == nil && other == nil {
@@ -76,15 +147,15 @@ func (is *IntString) Equal(other *IntString) bool {
 	if is == nil || other == nil {
 		return false
 	}
-	// Compare the underlying values based on their types.
-	switch v := is.Value.(type) {
-	case int:
-		ov, ok := other.Value.(int)
-		return ok && v == ov
-	case string:
-		ov, ok := other.Value.(string)
-		return ok && v == ov
-	default:
+	key := is.Key()
+	return key != "" && key == other.Key()
+}
+
+// isJSONNumber reports whether data is a single JSON number.
+func isJSONNumber(data []byte) bool {
+	if len(data) == 0 || (data[0] != '-' && (data[0] < '0' || data[0] > '9')) {
 		return false
 	}
+	var n json.Number
+	return json.Unmarshal(data, &n) == nil
 }
@@ -31,16 +31,38 @@ func TestIntString_UnmarshalJSON(t *testing.T) {
 			wantErr:   false,
 		},
 		{
-			name:       "Invalid type (float)",
-			input:      `123.45`,
-			wantErr:    true,
-			wantErrMsg: "IntString must be a string or an integer",
+			name:      "Fractional number",
+			input:     `123.45`,
+			wantValue: json.Number("123.45"),
+			wantIsInt: false,
+			wantErr:   false,
+		},
+		{
+			name:      "Integral number with fraction",
+			input:     `1.0`,
+			wantValue: json.Number("1.0"),
+			wantIsInt: false,
+			wantErr:   false,
+		},
+		{
+			name:      "Number with exponent",
+			input:     `1e3`,
+			wantValue: json.Number("1e3"),
+			wantIsInt: false,
+			wantErr:   false,
+		},
+		{
+			name:      "Integer overflowing int",
+			input:     `123456789012345678901234567890`,
+			wantValue: json.Number("123456789012345678901234567890"),
+			wantIsInt: false,
+			wantErr:   false,
 		},
 		{
 			name:       "Invalid type (boolean)",
 			input:      `true`,
 			wantErr:    true,
-			wantErrMsg: "IntString must be a string or an integer",
+			wantErrMsg: "IntString must be a string or a number",
 		},
 		{
 			name:       "Null value",
@@ -52,7 +74,7 @@ func TestIntString_UnmarshalJSON(t *testing.T) {
 			name:       "Invalid JSON",
 			input:      `{}`,
 			wantErr:    true,
-			wantErrMsg: "IntString must be a string or an integer",
+			wantErrMsg: "IntString must be a string or a number",
 		},
 		{
 			name:      "Empty string",
@@ -120,13 +142,13 @@ func TestIntString_UnmarshalJSON(t *testing.T) {
 			name:       "Array input",
 			input:      `["hello", 123]`,
 			wantErr:    true,
-			wantErrMsg: "IntString must be a string or an integer",
+			wantErrMsg: "IntString must be a string or a number",
 		},
 		{
 			name:       "Object input",
 			input:      `{"key": "value"}`,
 			wantErr:    true,
-			wantErrMsg: "IntString must be a string or an integer",
+			wantErrMsg: "IntString must be a string or a number",
 		},
 	}
 
@@ -157,8 +179,81 @@ func TestIntString_UnmarshalJSON(t *testing.T) {
 				t.Errorf("IntString.IsInt() = %v, want %v", is.IsInt(), tt.wantIsInt)
 			}
 
-			if is.IsString() != !tt.wantIsInt {
-				t.Errorf("IntString.IsString() = %v, want %v", is.IsString(), !tt.wantIsInt)
+			_, wantIsString := tt.wantValue.(string)
+			if is.IsString() != wantIsString {
+				t.Errorf("IntString.IsString() = %v, want %v", is.IsString(), wantIsString)
+			}
+
+			if is.IsNumber() == wantIsString {
+				t.Errorf("IntString.IsNumber() = %v, want %v", is.IsNumber(), !wantIsString)
+			}
+		})
+	}
+}
+
+func TestIntString_ExactEcho(t *testing.T) {
+	inputs := []string{`1`, `1.0`, `1e3`, `-0`, `123456789012345678901234567890`, `"1"`, `"a\u0026b"`}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			var resp Response[json.RawMessage]
+			data := `{"jsonrpc":"2.0","id":` + input + `,"result":null}`
+			if err := json.Unmarshal([]byte(data), &resp); err != nil {
+				t.Fatalf("Could not unmarshal response: %v", err)
+			}
+			out, err := json.Marshal(resp.ID)
+			if err != nil {
+				t.Fatalf("Could not marshal ID: %v", err)
+			}
+			if string(out) != input {
+				t.Errorf("Expected ID to be echoed as %s, got %s", input, out)
+			}
+		})
+	}
+
+	// A changed value is marshaled from the value.
+	var is IntString
+	if err := json.Unmarshal([]byte(`1.0`), &is); err != nil {
+		t.Fatalf("Could not unmarshal: %v", err)
+	}
+	is.Value = 2
+	if out, err := json.Marshal(is); err != nil || string(out) != `2` {
+		t.Errorf("Expected changed value to be marshaled as 2, got %s, %v", out, err)
+	}
+}
+
+func TestIntString_Equal(t *testing.T) {
+	decode := func(input string) *IntString {
+		var is IntString
+		if err := json.Unmarshal([]byte(input), &is); err != nil {
+			t.Fatalf("Could not unmarshal %s: %v", input, err)
+		}
+		return &is
+	}
+	tests := []struct {
+		name string
+		a, b *IntString
+		want bool
+	}{
+		{name: "Same int", a: decode(`1`), b: &IntString{Value: 1}, want: true},
+		{name: "Int and string", a: decode(`1`), b: decode(`"1"`), want: false},
+		{name: "Int and fraction", a: decode(`1`), b: decode(`1.0`), want: false},
+		{name: "Same fraction", a: decode(`1.0`), b: &IntString{Value: json.Number("1.0")}, want: true},
+		{
+			name: "Large numbers",
+			a:    decode(`123456789012345678901234567890`),
+			b:    decode(`123456789012345678901234567890`),
+			want: true,
+		},
+		{name: "Nil and value", a: nil, b: decode(`1`), want: false},
+		{name: "Both nil", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Errorf("IntString.Equal() = %v, want %v", got, tt.want)
+			}
+			if tt.a != nil && tt.b != nil && (tt.a.Key() == tt.b.Key()) != tt.want {
+				t.Errorf("IntString.Key() equality = %v, want %v", !tt.want, tt.want)
 			}
 		})
 	}
@@ -392,7 +487,7 @@ func TestIntString_ErrorMessages(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Expected error when unmarshaling array, but got none")
 	}
-	expectedErrMsg := "IntString must be a string or an integer"
+	expectedErrMsg := "IntString must be a string or a number"
 	if !strings.Contains(err.Error(), expectedErrMsg) {
 		t.Errorf("Error message = %v, want %v", err.Error(), expectedErrMsg)
 	}
@@ -0,0 +1,131 @@
+package reqresp
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Params can be validated before they are decoded, against the JSON Schema Huma generates for the
+// params type, which is the schema documented by the OpenAPI document of the Huma transports.
+// See the Huma documentation for the supported struct tags, e.g. `enum`, `minimum`, `maxLength`,
+// `pattern` or `format`. Positional params are validated as the object they bind to.
+//
+// Validation is enabled by the ValidateParams field of MethodHandler and NotificationHandler,
+// and by the WithParamsValidation option for services.
+
+// ValidationError describes a single params value that failed validation.
+type ValidationError struct {
+	// Location of the failing value within params, e.g. "tags[0]", "" for params itself.
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ValidationErrors is returned when params fail validation.
+// It is set as the Data of the resulting InvalidParamsError.
+type ValidationErrors []ValidationError
+
+// Error implements error.
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, e := range v {
+		location := e.Location
+		if location == "" {
+			location = "params"
+		}
+		msgs = append(msgs, location+": "+e.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// ParamsValidator validates params against the schema of a params type.
+// The schema is built once, so a validator is meant to be kept per handler.
+type ParamsValidator struct {
+	t        reflect.Type
+	registry huma.Registry
+	schema   *huma.Schema
+}
+
+// NewParamsValidator builds the validator of the params type t.
+func NewParamsValidator(t reflect.Type) *ParamsValidator {
+	registry := huma.NewMapRegistry("#/components/schemas/", huma.DefaultSchemaNamer)
+	return &ParamsValidator{t: t, registry: registry, schema: registry.Schema(t, true, "")}
+}
+
+// Validate validates params. Absent params are validated as an empty object if the params type is a
+// struct, and accepted otherwise. The error is ValidationErrors if params do not match the schema.
+func (v *ParamsValidator) Validate(params json.RawMessage) error {
+	if params == nil {
+		if v.t.Kind() != reflect.Struct {
+			return nil
+		}
+		params = json.RawMessage(`{}`)
+	}
+	var value any
+	if err := json.Unmarshal(params, &value); err != nil {
+		return err
+	}
+	if elems, ok := value.([]any); ok {
+		if layout := getPositionalLayout(v.t); layout != nil && layout.err == nil {
+			value = positionalToObject(elems, v.t, layout)
+		}
+	}
+
+	res := &huma.ValidateResult{}
+	huma.Validate(v.registry, v.schema, huma.NewPathBuffer([]byte{}, 0), huma.ModeWriteToServer, value, res)
+	if len(res.Errors) == 0 {
+		return nil
+	}
+	errs := make(ValidationErrors, 0, len(res.Errors))
+	for _, err := range res.Errors {
+		var detail *huma.ErrorDetail
+		if d, ok := err.(*huma.ErrorDetail); ok {
+			detail = d
+		} else {
+			detail = &huma.ErrorDetail{Message: err.Error()}
+		}
+		errs = append(errs, ValidationError{Location: detail.Location, Message: detail.Message})
+	}
+	slices.SortStableFunc(errs, func(a, b ValidationError) int {
+		return strings.Compare(a.Location, b.Location)
+	})
+	return errs
+}
+
+// lazyParamsValidator builds the ParamsValidator of a handler on first use.
+type lazyParamsValidator struct {
+	once      sync.Once
+	validator *ParamsValidator
+}
+
+func (l *lazyParamsValidator) get(t reflect.Type) *ParamsValidator {
+	l.once.Do(func() {
+		l.validator = NewParamsValidator(t)
+	})
+	return l.validator
+}
+
+// positionalToObject maps positional params to the properties of the struct fields they bind to.
+// Params exceeding the arity are left as an array, so that binding reports the arity error.
+func positionalToObject(elems []any, t reflect.Type, layout *positionalLayout) any {
+	if len(elems) > len(layout.fields) {
+		return elems
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	obj := make(map[string]any, len(elems))
+	for pos, elem := range elems {
+		field := t.FieldByIndex(layout.fields[pos])
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		obj[name] = elem
+	}
+	return obj
+}
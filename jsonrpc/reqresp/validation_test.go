@@ -0,0 +1,186 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// ValidationBase is exported, as Huma only flattens exported embedded structs.
+type ValidationBase struct {
+	Tags []string `json:"tags,omitempty" enum:"a,b"`
+}
+
+type validationParams struct {
+	ValidationBase
+
+	Name   string            `json:"name"`
+	Level  string            `json:"level"              enum:"debug,info"`
+	Count  int               `json:"count"              minimum:"1"       maximum:"10"`
+	Ratio  *float64          `json:"ratio,omitempty"`
+	Note   string            `json:"note,omitempty"     required:"true"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Child  *validationParams `json:"child,omitempty"`
+}
+
+type formatParams struct {
+	Code  string  `json:"code"            minLength:"2"        maxLength:"3" pattern:"^[a-z]+$"`
+	Email string  `json:"email,omitempty" format:"email"`
+	Step  int     `json:"step,omitempty"  multipleOf:"5"`
+	Ratio float64 `json:"ratio,omitempty" exclusiveMinimum:"0" exclusiveMaximum:"1"`
+}
+
+type openParams struct {
+	_    struct{} `json:"-"    additionalProperties:"true"`
+	Name string   `json:"name"`
+}
+
+func TestValidateParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		iType   reflect.Type
+		wantErr ValidationErrors
+	}{
+		{
+			name:   "Valid params",
+			params: `{"name":"x","level":"info","count":3,"note":"","tags":["a"],"child":null}`,
+			iType:  reflect.TypeOf(validationParams{}),
+		},
+		{
+			name:   "Missing required properties",
+			params: `{"name":"x","level":"info"}`,
+			iType:  reflect.TypeOf(validationParams{}),
+			wantErr: ValidationErrors{
+				{Location: "", Message: "expected required property count to be present"},
+				{Location: "", Message: "expected required property note to be present"},
+			},
+		},
+		{
+			name:   "Enum, range and type violations",
+			params: `{"name":1,"level":"warn","count":11,"note":"","tags":["c"],"ratio":"x","labels":{"k":2}}`,
+			iType:  reflect.TypeOf(validationParams{}),
+			wantErr: ValidationErrors{
+				{Location: "count", Message: "expected number <= 10"},
+				{Location: "labels.k", Message: "expected string"},
+				{Location: "level", Message: `expected value to be one of "debug, info"`},
+				{Location: "name", Message: "expected string"},
+				{Location: "ratio", Message: "expected number"},
+				{Location: "tags[0]", Message: `expected value to be one of "a, b"`},
+			},
+		},
+		{
+			name:   "Nested and unexpected properties",
+			params: `{"name":"x","level":"info","count":1,"note":"","child":{"name":"y","level":"info","count":0.5,"note":"","a/b":1}}`,
+			iType:  reflect.TypeOf(validationParams{}),
+			wantErr: ValidationErrors{
+				{Location: "child.a/b", Message: "unexpected property"},
+				{Location: "child.count", Message: "expected integer"},
+				{Location: "child.count", Message: "expected number >= 1"},
+			},
+		},
+		{
+			name:   "Additional properties allowed",
+			params: `{"name":"x","extra":true}`,
+			iType:  reflect.TypeOf(openParams{}),
+		},
+		{
+			name:    "Absent params for struct",
+			iType:   reflect.TypeOf(openParams{}),
+			wantErr: ValidationErrors{{Location: "", Message: "expected required property name to be present"}},
+		},
+		{
+			name:  "Absent params for pointer",
+			iType: reflect.TypeOf(&openParams{}),
+		},
+		{
+			name:    "Positional params",
+			params:  `[1,"x"]`,
+			iType:   reflect.TypeOf(positionalAddParams{}),
+			wantErr: ValidationErrors{{Location: "b", Message: "expected integer"}},
+		},
+		{
+			name:   "String, format and number keywords",
+			params: `{"code":"ABCD","email":"x","step":7,"ratio":1}`,
+			iType:  reflect.TypeOf(formatParams{}),
+			wantErr: ValidationErrors{
+				{Location: "code", Message: "expected length <= 3"},
+				{Location: "code", Message: "expected string to match pattern ^[a-z]+$"},
+				{Location: "email", Message: "expected string to be RFC 5322 email: mail: missing '@' or angle-addr"},
+				{Location: "ratio", Message: "expected number < 1"},
+				{Location: "step", Message: "expected number to be a multiple of 5"},
+			},
+		},
+		{
+			name:    "Wrong params type",
+			params:  `"x"`,
+			iType:   reflect.TypeOf(openParams{}),
+			wantErr: ValidationErrors{{Location: "", Message: "expected object"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var params json.RawMessage
+			if tc.params != "" {
+				params = json.RawMessage(tc.params)
+			}
+			err := NewParamsValidator(tc.iType).Validate(params)
+			if tc.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			var got ValidationErrors
+			if !errors.As(err, &got) {
+				t.Fatalf("Expected ValidationErrors, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.wantErr) {
+				t.Errorf("Unexpected errors.\nGot:  %#v\nWant: %#v", got, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMethodHandlerValidateParams(t *testing.T) {
+	called := false
+	brh := NewBatchRequestHandler(WithMethodMap(map[string]IMethodHandler{
+		"log": &MethodHandler[validationParams, struct{}]{
+			Endpoint: func(ctx context.Context, params validationParams) (struct{}, error) {
+				called = true
+				return struct{}{}, nil
+			},
+			ValidateParams: true,
+		},
+	}))
+
+	resp, err := brh.Handle(t.Context(), &BatchRequest{Body: &BatchItem[UnionRequest]{
+		Items: []UnionRequest{{
+			JSONRPC: JSONRPCVersion,
+			Method:  stringToPointer("log"),
+			Params:  json.RawMessage(`{"name":"x","level":"warn","count":1,"note":""}`),
+			ID:      &RequestID{Value: 1},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if called {
+		t.Errorf("Expected endpoint not to be called for invalid params")
+	}
+	item := resp.Body.Items[0]
+	if item.Error == nil || item.Error.Code != InvalidParamsError {
+		t.Fatalf("Expected invalid params error, got %#v", item)
+	}
+	data, err := json.Marshal(item.Error.Data)
+	if err != nil {
+		t.Fatalf("Could not marshal error data: %v", err)
+	}
+	want := `[{"location":"level","message":"expected value to be one of \"debug, info\""}]`
+	if !jsonStringsEqual(string(data), want) {
+		t.Errorf("Unexpected error data. Got: %s, Want: %s", data, want)
+	}
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// InMemoryExporter keeps exported spans in memory, e.g. for tests.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter creates an exporter without spans.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan implements Exporter.
+func (e *InMemoryExporter) ExportSpan(ctx context.Context, span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans, in the order they ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Reset removes all exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// ExporterFunc adapts a function to an Exporter.
+type ExporterFunc func(ctx context.Context, span SpanData)
+
+// ExportSpan implements Exporter.
+func (f ExporterFunc) ExportSpan(ctx context.Context, span SpanData) {
+	f(ctx, span)
+}
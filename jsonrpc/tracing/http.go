@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// W3C trace context headers.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// ExtractHTTP returns a context holding the trace context in header, if any.
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceParent(header.Get(TraceParentHeader))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = header.Get(TraceStateHeader)
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// InjectHTTP sets the trace context of ctx in header, if any.
+func InjectHTTP(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(TraceParentHeader, sc.TraceParent())
+	if sc.TraceState != "" {
+		header.Set(TraceStateHeader, sc.TraceState)
+	}
+}
+
+// HumaMiddleware reads the trace context from the headers of requests, so that spans of the
+// dispatcher are children of the span of the caller.
+//
+//	api.UseMiddleware(tracing.HumaMiddleware)
+func HumaMiddleware(ctx huma.Context, next func(huma.Context)) {
+	if ctx.Header(TraceParentHeader) == "" {
+		next(ctx)
+		return
+	}
+	header := http.Header{}
+	header.Set(TraceParentHeader, ctx.Header(TraceParentHeader))
+	header.Set(TraceStateHeader, ctx.Header(TraceStateHeader))
+	next(huma.WithContext(ctx, ExtractHTTP(ctx.Context(), header)))
+}
+
+// Transport is an http.RoundTripper setting the trace context of the request context in the
+// headers of requests, e.g. for the http.Client of the HTTP transports.
+type Transport struct {
+	// Base sends the requests. The default is http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if SpanContextFromContext(req.Context()).IsValid() {
+		// RoundTrippers must not modify the request.
+		req = req.Clone(req.Context())
+		InjectHTTP(req.Context(), req.Header)
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,277 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// Keys of the trace context in params.
+const (
+	MetaKey        = "_meta"
+	TraceParentKey = "traceparent"
+	TraceStateKey  = "tracestate"
+)
+
+// BatchSpanName is the name of the spans of batches. Spans of methods and notifications are
+// named after the method.
+const BatchSpanName = "jsonrpc.batch"
+
+// HandlerOptions returns the options tracing a BatchRequestHandler: a span per batch, with a child
+// span per method and notification. A trace context in the _meta field of params takes precedence
+// over the batch span as parent.
+func HandlerOptions(tracer *Tracer) []jsonrpcReqResp.HandlerOption {
+	return []jsonrpcReqResp.HandlerOption{
+		jsonrpcReqResp.WithBatchMiddleware(tracer.BatchMiddleware()),
+		jsonrpcReqResp.WithMethodMiddleware(tracer.MethodMiddleware()),
+		jsonrpcReqResp.WithNotificationMiddleware(tracer.NotificationMiddleware()),
+	}
+}
+
+// BatchMiddleware returns a middleware starting a span per batch.
+func (t *Tracer) BatchMiddleware() jsonrpcReqResp.BatchMiddleware {
+	return func(next jsonrpcReqResp.BatchHandlerFunc) jsonrpcReqResp.BatchHandlerFunc {
+		return func(
+			ctx context.Context,
+			req *jsonrpcReqResp.BatchRequest,
+		) (*jsonrpcReqResp.BatchResponse, error) {
+			ctx, span := t.Start(ctx, BatchSpanName, SpanKindServer)
+			defer span.End()
+			span.SetAttribute(AttrRPCSystem, RPCSystemJSONRPC)
+			if req != nil && req.Body != nil {
+				span.SetAttribute(AttrBatchSize, len(req.Body.Items))
+				span.SetAttribute(AttrIsBatch, req.Body.IsBatch)
+			}
+			resp, err := next(ctx, req)
+			span.RecordError(err)
+			return resp, err
+		}
+	}
+}
+
+// MethodMiddleware returns a middleware starting a span per method, with the method name,
+// request ID and error code as attributes.
+func (t *Tracer) MethodMiddleware() jsonrpcReqResp.MethodMiddleware {
+	return func(next jsonrpcReqResp.IMethodHandler) jsonrpcReqResp.IMethodHandler {
+		return jsonrpcReqResp.WrapMethodHandler(next, func(
+			ctx context.Context,
+			req jsonrpcReqResp.Request[json.RawMessage],
+		) jsonrpcReqResp.Response[json.RawMessage] {
+			ctx, span := t.Start(ExtractMeta(ctx, req.Params), req.Method, SpanKindServer)
+			defer span.End()
+			defer recordPanic(span)
+			span.SetAttribute(AttrRPCSystem, RPCSystemJSONRPC)
+			span.SetAttribute(AttrRPCMethod, req.Method)
+			span.SetAttribute(AttrRequestID, requestIDValue(req.ID))
+
+			resp := next.Handle(ctx, req)
+			recordResponseError(span, resp.Error)
+			return resp
+		})
+	}
+}
+
+// NotificationMiddleware returns a middleware starting a span per notification.
+func (t *Tracer) NotificationMiddleware() jsonrpcReqResp.NotificationMiddleware {
+	return func(next jsonrpcReqResp.INotificationHandler) jsonrpcReqResp.INotificationHandler {
+		return jsonrpcReqResp.WrapNotificationHandler(next, func(
+			ctx context.Context,
+			req jsonrpcReqResp.Notification[json.RawMessage],
+		) error {
+			ctx, span := t.Start(ExtractMeta(ctx, req.Params), req.Method, SpanKindServer)
+			defer span.End()
+			defer recordPanic(span)
+			span.SetAttribute(AttrRPCSystem, RPCSystemJSONRPC)
+			span.SetAttribute(AttrRPCMethod, req.Method)
+
+			err := next.Handle(ctx, req)
+			span.RecordError(err)
+			return err
+		})
+	}
+}
+
+// CallerOption configures a tracing caller.
+type CallerOption func(*tracingCaller)
+
+// WithMetaInjection adds the trace context of the client spans to the _meta field of the object params
+// of methods, or of all methods if none are given. Only methods whose params declare a _meta field
+// should be listed: servers validating params reject unknown fields, e.g. the Huma transports and
+// handlers with ValidateParams.
+func WithMetaInjection(methods ...string) CallerOption {
+	return func(c *tracingCaller) {
+		c.injectMeta = func(method string) bool {
+			return len(methods) == 0 || slices.Contains(methods, method)
+		}
+	}
+}
+
+// Caller returns a caller tracing the messages sent with caller, with a client span per message.
+// The trace context is not added to params unless WithMetaInjection is given. Over HTTP, it can be
+// sent in headers instead, see Transport.
+func (t *Tracer) Caller(caller jsonrpcReqResp.Caller, opts ...CallerOption) jsonrpcReqResp.Caller {
+	c := &tracingCaller{tracer: t, next: caller, injectMeta: func(string) bool { return false }}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type tracingCaller struct {
+	tracer *Tracer
+	next   jsonrpcReqResp.Caller
+	// injectMeta reports whether the trace context is added to the params of method.
+	injectMeta func(method string) bool
+}
+
+func (c *tracingCaller) SendContext(ctx context.Context, msg []byte) ([]byte, error) {
+	var batch jsonrpcReqResp.BatchItem[map[string]json.RawMessage]
+	if err := jsonrpcReqResp.DefaultCodec().Unmarshal(msg, &batch); err != nil || len(batch.Items) == 0 {
+		// Not a message that can be traced, send it as is.
+		return c.next.SendContext(ctx, msg)
+	}
+
+	name := BatchSpanName
+	var method string
+	if !batch.IsBatch {
+		if err := json.Unmarshal(batch.Items[0]["method"], &method); err == nil {
+			name = method
+		}
+	}
+	ctx, span := c.tracer.Start(ctx, name, SpanKindClient)
+	defer span.End()
+	span.SetAttribute(AttrRPCSystem, RPCSystemJSONRPC)
+	if batch.IsBatch {
+		span.SetAttribute(AttrBatchSize, len(batch.Items))
+		span.SetAttribute(AttrIsBatch, true)
+	} else if method != "" {
+		span.SetAttribute(AttrRPCMethod, method)
+		var id jsonrpcReqResp.RequestID
+		if err := json.Unmarshal(batch.Items[0]["id"], &id); err == nil {
+			span.SetAttribute(AttrRequestID, requestIDValue(id))
+		}
+	}
+
+	for _, item := range batch.Items {
+		var itemMethod string
+		if json.Unmarshal(item["method"], &itemMethod) != nil || !c.injectMeta(itemMethod) {
+			continue
+		}
+		if params, ok := item["params"]; ok {
+			injected, err := InjectMeta(ctx, params)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			item["params"] = injected
+		}
+	}
+	out, err := jsonrpcReqResp.DefaultCodec().Marshal(batch)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	reply, err := c.next.SendContext(ctx, out)
+	if err != nil {
+		span.RecordError(err)
+		return reply, err
+	}
+	if !batch.IsBatch {
+		var resp struct {
+			Error *jsonrpcReqResp.JSONRPCError `json:"error"`
+		}
+		if json.Unmarshal(reply, &resp) == nil {
+			recordResponseError(span, resp.Error)
+		}
+	}
+	return reply, nil
+}
+
+// ExtractMeta returns a context holding the trace context in the _meta field of params, if any.
+func ExtractMeta(ctx context.Context, params json.RawMessage) context.Context {
+	if !isObject(params) {
+		return ctx
+	}
+	var holder struct {
+		Meta struct {
+			TraceParent string `json:"traceparent"`
+			TraceState  string `json:"tracestate"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &holder); err != nil || holder.Meta.TraceParent == "" {
+		return ctx
+	}
+	sc, err := ParseTraceParent(holder.Meta.TraceParent)
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = holder.Meta.TraceState
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// InjectMeta returns params with the trace context of ctx in their _meta field.
+// Params that are not a JSON object, and contexts without a span, leave params unchanged.
+func InjectMeta(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() || !isObject(params) {
+		return params, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return nil, fmt.Errorf("inject trace context: %w", err)
+	}
+	meta := make(map[string]json.RawMessage)
+	if raw, ok := fields[MetaKey]; ok && isObject(raw) {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("inject trace context: %w", err)
+		}
+	}
+	meta[TraceParentKey], _ = json.Marshal(sc.TraceParent())
+	if sc.TraceState != "" {
+		meta[TraceStateKey], _ = json.Marshal(sc.TraceState)
+	}
+	var err error
+	if fields[MetaKey], err = json.Marshal(meta); err != nil {
+		return nil, fmt.Errorf("inject trace context: %w", err)
+	}
+	return json.Marshal(fields)
+}
+
+func isObject(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+// requestIDValue returns the request ID as a string or a json.Number.
+func requestIDValue(id jsonrpcReqResp.RequestID) any {
+	if s, ok := id.StringValue(); ok {
+		return s
+	}
+	if n, ok := id.NumberValue(); ok {
+		return n
+	}
+	return nil
+}
+
+func recordResponseError(span *Span, jerr *jsonrpcReqResp.JSONRPCError) {
+	if jerr == nil {
+		return
+	}
+	span.SetAttribute(AttrErrorCode, int(jerr.Code))
+	span.SetAttribute(AttrErrorMessage, jerr.Message)
+	span.SetStatus(StatusError, jerr.Message)
+}
+
+// recordPanic marks the span as failed if the handler panics, and lets the panic continue to the
+// recovery of the dispatcher.
+func recordPanic(span *Span) {
+	if r := recover(); r != nil {
+		span.SetStatus(StatusError, fmt.Sprintf("panic: %v", r))
+		panic(r)
+	}
+}
@@ -0,0 +1,287 @@
+// Package tracing traces the JSONRPC dispatcher, with spans per batch, method and notification.
+//
+// The API follows OpenTelemetry: spans have a W3C trace context, attributes and a status, and are
+// handed to a pluggable Exporter when they end. Trace context travels between peers as the W3C
+// headers "traceparent" and "tracestate" on the HTTP transports, and, for methods whose params
+// declare it, in the keys of the same names of the "_meta" field of params.
+//
+// Example:
+//
+//	exporter := tracing.NewInMemoryExporter()
+//	tracer := tracing.NewTracer(exporter)
+//	handler := reqresp.NewBatchRequestHandler(append(
+//	    tracing.HandlerOptions(tracer),
+//	    reqresp.WithRegistry(registry),
+//	)...)
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the lowercase hex encoding of the ID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// String returns the lowercase hex encoding of the ID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// FlagsSampled is the trace flag set for sampled traces.
+const FlagsSampled byte = 0x01
+
+// SpanContext is the part of a span that is propagated to other peers.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	TraceFlags byte
+	TraceState string
+	// Remote is set if the span context was received from another peer.
+	Remote bool
+}
+
+// IsValid reports whether both IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// TraceParent returns the span context in the W3C traceparent format.
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.TraceFlags)
+}
+
+// ParseTraceParent parses a span context in the W3C traceparent format. The result is remote.
+func ParseTraceParent(traceParent string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", traceParent)
+	}
+	var sc SpanContext
+	var flags [1]byte
+	for _, field := range []struct {
+		dst []byte
+		src string
+	}{
+		{sc.TraceID[:], parts[1]},
+		{sc.SpanID[:], parts[2]},
+		{flags[:], parts[3]},
+	} {
+		if len(field.src) != 2*len(field.dst) || strings.ToLower(field.src) != field.src {
+			return SpanContext{}, fmt.Errorf("invalid traceparent %q", traceParent)
+		}
+		if _, err := hex.Decode(field.dst, []byte(field.src)); err != nil {
+			return SpanContext{}, fmt.Errorf("invalid traceparent %q: %w", traceParent, err)
+		}
+	}
+	sc.TraceFlags = flags[0]
+	sc.Remote = true
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: zero ID", traceParent)
+	}
+	return sc, nil
+}
+
+// SpanKind is the role of a span in a call between peers.
+type SpanKind string
+
+// Constants for the kinds of spans.
+const (
+	SpanKindInternal SpanKind = "internal"
+	SpanKindServer   SpanKind = "server"
+	SpanKindClient   SpanKind = "client"
+)
+
+// StatusCode is the outcome of a span.
+type StatusCode string
+
+// Constants for the outcomes of spans.
+const (
+	StatusUnset StatusCode = "unset"
+	StatusOK    StatusCode = "ok"
+	StatusError StatusCode = "error"
+)
+
+// Attribute keys set by this package, following the OpenTelemetry RPC conventions.
+const (
+	AttrRPCSystem    = "rpc.system"
+	AttrRPCMethod    = "rpc.method"
+	AttrRequestID    = "rpc.jsonrpc.request_id"
+	AttrErrorCode    = "rpc.jsonrpc.error_code"
+	AttrErrorMessage = "rpc.jsonrpc.error_message"
+	AttrBatchSize    = "rpc.jsonrpc.batch_size"
+	AttrIsBatch      = "rpc.jsonrpc.is_batch"
+)
+
+// RPCSystemJSONRPC is the value of the AttrRPCSystem attribute.
+const RPCSystemJSONRPC = "jsonrpc"
+
+// SpanData is a finished span, as handed to exporters.
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanContext
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]any
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Exporter receives spans when they end. Implementations must be safe for concurrent use.
+type Exporter interface {
+	ExportSpan(ctx context.Context, span SpanData)
+}
+
+// Tracer starts spans and hands them to its exporter.
+type Tracer struct {
+	exporter Exporter
+	now      func() time.Time
+}
+
+// TracerOption configures a Tracer.
+type TracerOption func(*Tracer)
+
+// WithClock sets the function returning the current time, e.g. for tests.
+func WithClock(now func() time.Time) TracerOption {
+	return func(t *Tracer) {
+		t.now = now
+	}
+}
+
+// NewTracer creates a tracer exporting spans to exporter.
+func NewTracer(exporter Exporter, opts ...TracerOption) *Tracer {
+	t := &Tracer{exporter: exporter, now: time.Now}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Start starts a span, whose parent is the span context in ctx, if any.
+// The returned context holds the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{TraceFlags: FlagsSampled}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.TraceFlags = parent.TraceFlags
+		sc.TraceState = parent.TraceState
+	} else {
+		_, _ = rand.Read(sc.TraceID[:])
+	}
+	_, _ = rand.Read(sc.SpanID[:])
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:        name,
+			Kind:        kind,
+			SpanContext: sc,
+			Parent:      parent,
+			StartTime:   t.now(),
+			Attributes:  make(map[string]any),
+			Status:      StatusUnset,
+		},
+	}
+	ctx = context.WithValue(ctx, ctxKeySpan, span)
+	return context.WithValue(ctx, ctxKeySpanContext, sc), span
+}
+
+// Span is a span being recorded. Its methods are safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the span context of the span.
+func (s *Span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+// SetStatus sets the outcome of the span.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = code
+	s.data.StatusMessage = message
+}
+
+// RecordError sets the status of the span to error with the message of err, if err is not nil.
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End finishes the span and exports it. Calls after the first one are ignored.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = s.tracer.now()
+	data := s.data
+	data.Attributes = maps.Clone(s.data.Attributes)
+	s.mu.Unlock()
+
+	s.tracer.exporter.ExportSpan(context.Background(), data)
+}
+
+type contextKey string
+
+const (
+	ctxKeySpan        contextKey = "span"
+	ctxKeySpanContext contextKey = "spanContext"
+)
+
+// SpanFromContext returns the span in ctx, nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(ctxKeySpan).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the span context of the last span started from ctx, or the
+// remote span context set with ContextWithRemoteSpanContext, whichever was set last.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(ctxKeySpanContext).(SpanContext)
+	return sc
+}
+
+// ContextWithRemoteSpanContext returns a context whose spans are children of sc, received from
+// another peer. It takes precedence over the span already in ctx.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	sc.Remote = true
+	return context.WithValue(ctx, ctxKeySpanContext, sc)
+}
@@ -0,0 +1,318 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/ppipada/go-mcp-expt/jsonrpc/humaadapter"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+	"github.com/ppipada/go-mcp-expt/jsonrpc/transport/httponly"
+)
+
+type AddParams struct {
+	Meta map[string]any `json:"_meta,omitempty"`
+	A    int            `json:"a"`
+	B    int            `json:"b"`
+}
+
+// SubParams has no _meta field, so validated params with a trace context are rejected.
+type SubParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type LogParams struct {
+	Message string `json:"message"`
+}
+
+// callerFunc is a Caller calling the function.
+type callerFunc func(ctx context.Context, msg []byte) ([]byte, error)
+
+func (f callerFunc) SendContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return f(ctx, msg)
+}
+
+func getRegistry() *jsonrpcReqResp.Registry {
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[AddParams, int]{
+		Endpoint: func(ctx context.Context, params AddParams) (int, error) {
+			return params.A + params.B, nil
+		},
+	})
+	registry.RegisterMethod("fail", &jsonrpcReqResp.MethodHandler[*struct{}, *struct{}]{
+		Endpoint: func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+			return nil, errors.New("failed")
+		},
+	})
+	registry.RegisterNotification("log", &jsonrpcReqResp.NotificationHandler[LogParams]{
+		Endpoint: func(ctx context.Context, params LogParams) error {
+			return nil
+		},
+	})
+	return registry
+}
+
+func spansByName(spans []SpanData) map[string]SpanData {
+	byName := make(map[string]SpanData, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	return byName
+}
+
+func TestTraceParent(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceParent(traceParent)
+	if err != nil {
+		t.Fatalf("ParseTraceParent returned error: %v", err)
+	}
+	if !sc.IsValid() || !sc.Remote || sc.TraceFlags != FlagsSampled {
+		t.Errorf("Unexpected span context %+v", sc)
+	}
+	if got := sc.TraceParent(); got != traceParent {
+		t.Errorf("Expected %s, got %s", traceParent, got)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceParent(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestHandlerSpans(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+	handler := jsonrpcReqResp.NewBatchRequestHandler(append(
+		HandlerOptions(tracer),
+		jsonrpcReqResp.WithRegistry(getRegistry()),
+	)...)
+	peer := jsonrpcReqResp.NewPeer(handler, nil)
+
+	const remote = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	msg := `[
+		{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a": 1, "b": 2, "_meta": {"traceparent": "` + remote + `"}}},
+		{"jsonrpc": "2.0", "id": "x", "method": "fail"},
+		{"jsonrpc": "2.0", "method": "log", "params": {"message": "hi"}}
+	]`
+	if _, err := peer.HandleMessage(t.Context(), []byte(msg)); err != nil {
+		t.Fatalf("HandleMessage returned error: %v", err)
+	}
+
+	spans := spansByName(exporter.Spans())
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %+v", exporter.Spans())
+	}
+	batch := spans[BatchSpanName]
+	if batch.Parent.IsValid() || batch.Attributes[AttrBatchSize] != 3 ||
+		batch.Attributes[AttrIsBatch] != true {
+		t.Errorf("Unexpected batch span %+v", batch)
+	}
+
+	add := spans["add"]
+	if add.Parent.TraceParent() != remote || !add.Parent.Remote {
+		t.Errorf("Expected add span to be a child of the _meta trace context, got %+v", add.Parent)
+	}
+	if add.SpanContext.TraceID != add.Parent.TraceID {
+		t.Errorf("Expected add span to be in the remote trace")
+	}
+	if add.Kind != SpanKindServer || add.Attributes[AttrRPCMethod] != "add" ||
+		add.Attributes[AttrRequestID] != json.Number("1") || add.Status != StatusUnset {
+		t.Errorf("Unexpected add span %+v", add)
+	}
+
+	fail := spans["fail"]
+	if fail.Parent.SpanID != batch.SpanContext.SpanID {
+		t.Errorf("Expected fail span to be a child of the batch span")
+	}
+	if fail.Attributes[AttrRequestID] != "x" ||
+		fail.Attributes[AttrErrorCode] != int(jsonrpcReqResp.InternalError) ||
+		fail.Status != StatusError {
+		t.Errorf("Unexpected fail span %+v", fail)
+	}
+
+	log := spans["log"]
+	if log.Parent.SpanID != batch.SpanContext.SpanID || log.Attributes[AttrRPCMethod] != "log" {
+		t.Errorf("Unexpected log span %+v", log)
+	}
+	if _, ok := log.Attributes[AttrRequestID]; ok {
+		t.Errorf("Expected no request ID on notification span")
+	}
+}
+
+func TestCallerPropagation(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+	handler := jsonrpcReqResp.NewBatchRequestHandler(append(
+		HandlerOptions(tracer),
+		jsonrpcReqResp.WithRegistry(getRegistry()),
+	)...)
+	peer := jsonrpcReqResp.NewPeer(handler, nil)
+	// The trace context only reaches the handler through _meta.
+	caller := tracer.Caller(callerFunc(func(_ context.Context, msg []byte) ([]byte, error) {
+		return peer.HandleMessage(context.Background(), msg)
+	}), WithMetaInjection("add"))
+
+	ctx, root := tracer.Start(t.Context(), "root", SpanKindInternal)
+	sum, err := jsonrpcReqResp.Call[AddParams, int](ctx, caller, "add", AddParams{A: 2, B: 3})
+	root.End()
+	if err != nil || sum != 5 {
+		t.Fatalf("Expected 5, got %d, %v", sum, err)
+	}
+
+	var client, server SpanData
+	for _, span := range exporter.Spans() {
+		if span.Name == "add" && span.Kind == SpanKindClient {
+			client = span
+		} else if span.Name == "add" && span.Kind == SpanKindServer {
+			server = span
+		}
+	}
+	if client.Parent.SpanID != root.SpanContext().SpanID {
+		t.Errorf("Expected client span to be a child of the root span")
+	}
+	if server.Parent.SpanID != client.SpanContext.SpanID || !server.Parent.Remote {
+		t.Errorf("Expected server span to be a child of the client span, got %+v", server.Parent)
+	}
+	if server.SpanContext.TraceID != root.SpanContext().TraceID {
+		t.Errorf("Expected one trace")
+	}
+}
+
+func TestCallerStrictParams(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+	registry := getRegistry()
+	registry.RegisterMethod("sub", &jsonrpcReqResp.MethodHandler[SubParams, int]{
+		Endpoint: func(ctx context.Context, params SubParams) (int, error) {
+			return params.A - params.B, nil
+		},
+		ValidateParams: true,
+	})
+	router := http.NewServeMux()
+	api := humago.New(router, huma.DefaultConfig("Test API", "1.0.0"))
+	api.UseMiddleware(HumaMiddleware)
+	op := humaadapter.GetDefaultOperation()
+	op.Path = httponly.JSONRPCEndpoint
+	humaadapter.RegisterRegistry(api, op, registry, HandlerOptions(tracer)...)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	client := httponly.NewClient(server.URL+httponly.JSONRPCEndpoint, &http.Client{Transport: &Transport{}})
+	caller := tracer.Caller(client, WithMetaInjection("add"))
+
+	ctx, root := tracer.Start(t.Context(), "root", SpanKindInternal)
+	diff, err := jsonrpcReqResp.Call[SubParams, int](ctx, caller, "sub", SubParams{A: 5, B: 3})
+	if err != nil || diff != 2 {
+		t.Fatalf("Expected 2, got %d, %v", diff, err)
+	}
+	sum, err := jsonrpcReqResp.Call[AddParams, int](ctx, caller, "add", AddParams{A: 2, B: 3})
+	root.End()
+	if err != nil || sum != 5 {
+		t.Fatalf("Expected 5, got %d, %v", sum, err)
+	}
+
+	// The trace context of sub reaches the server in the headers only.
+	for _, span := range exporter.Spans() {
+		if span.SpanContext.TraceID != root.SpanContext().TraceID {
+			t.Errorf("Expected span %s in the trace of the root span", span.Name)
+		}
+	}
+}
+
+func TestInjectMeta(t *testing.T) {
+	tracer := NewTracer(NewInMemoryExporter())
+	ctx, span := tracer.Start(t.Context(), "root", SpanKindInternal)
+	defer span.End()
+
+	params, err := InjectMeta(ctx, json.RawMessage(`{"a":1,"_meta":{"progressToken":"p"}}`))
+	if err != nil {
+		t.Fatalf("InjectMeta returned error: %v", err)
+	}
+	var out struct {
+		A    int               `json:"a"`
+		Meta map[string]string `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &out); err != nil {
+		t.Fatalf("Invalid params: %v", err)
+	}
+	if out.A != 1 || out.Meta["progressToken"] != "p" ||
+		out.Meta[TraceParentKey] != span.SpanContext().TraceParent() {
+		t.Errorf("Unexpected params %s", params)
+	}
+	got := SpanContextFromContext(ExtractMeta(t.Context(), params))
+	if got.SpanID != span.SpanContext().SpanID {
+		t.Errorf("Expected extracted span context to match, got %+v", got)
+	}
+
+	positional := json.RawMessage(`[1,2]`)
+	if params, err := InjectMeta(ctx, positional); err != nil || string(params) != string(positional) {
+		t.Errorf("Expected positional params unchanged, got %s, %v", params, err)
+	}
+}
+
+func TestHTTPPropagation(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+	_, api := humatest.New(t)
+	api.UseMiddleware(HumaMiddleware)
+	humaadapter.RegisterRegistry(
+		api,
+		humaadapter.GetDefaultOperation(),
+		getRegistry(),
+		HandlerOptions(tracer)...,
+	)
+
+	const remote = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	resp := api.Post("/jsonrpc", TraceParentHeader+": "+remote, TraceStateHeader+": k=v",
+		map[string]any{"jsonrpc": "2.0", "id": 1, "method": "add", "params": map[string]any{"a": 1, "b": 2}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", resp.Code, resp.Body.String())
+	}
+	batch := spansByName(exporter.Spans())[BatchSpanName]
+	if batch.Parent.TraceParent() != remote || batch.SpanContext.TraceState != "k=v" {
+		t.Errorf("Expected batch span to be a child of the header trace context, got %+v", batch)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(NewInMemoryExporter())
+	ctx, span := tracer.Start(t.Context(), "root", SpanKindClient)
+	defer span.End()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: &Transport{}}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Get(TraceParentHeader) != span.SpanContext().TraceParent() {
+		t.Errorf("Expected traceparent header, got %q", got.Get(TraceParentHeader))
+	}
+	if req.Header.Get(TraceParentHeader) != "" {
+		t.Errorf("Expected original request to be unchanged")
+	}
+}
@@ -2,14 +2,17 @@ package helpers_test
 
 import (
 	"context"
+	"maps"
+	"slices"
 
 	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
 )
 
 // AddParams defines the parameters for the "add" method.
+// The params can also be sent by position.
 type AddParams struct {
-	A int `json:"a"`
-	B int `json:"b"`
+	A int `json:"a" jsonrpc:"pos=0"`
+	B int `json:"b" jsonrpc:"pos=1"`
 }
 
 type AddResult struct {
@@ -31,6 +34,15 @@ type PingParams struct {
 	Message string `json:"message"`
 }
 
+// PeerInfoResult is the result of the "peerinfo" method.
+type PeerInfoResult struct {
+	Transport  string `json:"transport"`
+	RemoteAddr string `json:"remoteAddr"`
+	UserAgent  string `json:"userAgent,omitempty"`
+	// HeaderNames are the names of the headers of the peer.
+	HeaderNames []string `json:"headerNames,omitempty"`
+}
+
 // AddEndpoint is the handler for the "add" method.
 func AddEndpoint(ctx context.Context, params AddParams) (AddResult, error) {
 	res := params.A + params.B
@@ -87,6 +99,17 @@ func GetMethodHandlers() map[string]jsonrpcReqResp.IMethodHandler {
 				return x, nil
 			},
 		},
+		"peerinfo": &jsonrpcReqResp.MethodHandler[*struct{}, PeerInfoResult]{
+			Endpoint: func(ctx context.Context, _ *struct{}) (PeerInfoResult, error) {
+				info, _ := jsonrpcReqResp.GetPeerInfo(ctx)
+				return PeerInfoResult{
+					Transport:   info.Transport,
+					RemoteAddr:  info.RemoteAddr,
+					UserAgent:   jsonrpcReqResp.GetHeader(ctx, "User-Agent"),
+					HeaderNames: slices.Sorted(maps.Keys(info.Header)),
+				}, nil
+			},
+		},
 		"echooptional": &jsonrpcReqResp.MethodHandler[*string, *string]{
 			Endpoint: func(ctx context.Context, e *string) (*string, error) {
 				return e, nil
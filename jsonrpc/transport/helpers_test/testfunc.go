@@ -71,6 +71,16 @@ func TestValidSingleRequests(t *testing.T, client JSONRPCClient) {
 			},
 			expectedResult: map[string]float64{"sum": 5},
 		},
+		{
+			name: "Add method with positional parameters bound to named fields",
+			request: map[string]any{
+				"jsonrpc": "2.0",
+				"method":  "add",
+				"params":  []any{2, 3},
+				"id":      4,
+			},
+			expectedResult: map[string]float64{"sum": 5},
+		},
 		{
 			name: "Echo method with no parameters",
 			request: map[string]any{
@@ -268,7 +278,34 @@ func TestInvalidSingleRequests(t *testing.T, client JSONRPCClient) {
 	}
 }
 
-func TestNotifications(t *testing.T, client JSONRPCClient) {
+func TestRequestIDEcho(t *testing.T, client JSONRPCClient) {
+	ids := []string{`7`, `1.0`, `1e3`, `123456789012345678901234567890`, `"7"`}
+	for _, id := range ids {
+		t.Run(id, func(t *testing.T) {
+			request := `{"jsonrpc":"2.0","method":"concat","params":{"s1":"a","s2":"b"},"id":` + id + `}`
+			respBody := sendJSONRPCRequest(t, client, []byte(request))
+
+			var response struct {
+				Result       any                          `json:"result"`
+				JSONRPCError *jsonrpcReqResp.JSONRPCError `json:"error"`
+				ID           json.RawMessage              `json:"id"`
+			}
+			if err := json.Unmarshal(respBody, &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+			if response.JSONRPCError != nil {
+				t.Fatalf("Expected no error, but got: %+v", response.JSONRPCError)
+			}
+			if string(response.ID) != id {
+				t.Errorf("Expected ID %s to be echoed exactly, got %s", id, string(response.ID))
+			}
+		})
+	}
+}
+
+// TestNotifications checks that notifications get no response. Transports answering asynchronously,
+// e.g. SSE, send nothing for them and set emptyReplies.
+func TestNotifications(t *testing.T, client JSONRPCClient, emptyReplies bool) {
 	tests := []struct {
 		name          string
 		request       any
@@ -303,6 +340,9 @@ func TestNotifications(t *testing.T, client JSONRPCClient) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			respBody := sendJSONRPCRequest(t, client, tc.request)
+			if emptyReplies && len(respBody) == 0 {
+				return
+			}
 			var o any
 			err := json.Unmarshal(respBody, &o)
 			if err != nil {
@@ -316,7 +356,62 @@ func TestNotifications(t *testing.T, client JSONRPCClient) {
 	}
 }
 
-func TestBatchRequests(t *testing.T, client JSONRPCClient) {
+func TestTypedCalls(t *testing.T, caller jsonrpcReqResp.Caller) {
+	ctx := t.Context()
+	sum, err := jsonrpcReqResp.Call[AddParams, AddResult](ctx, caller, "add", AddParams{A: 2, B: 3})
+	if err != nil {
+		t.Fatalf("Call add returned error: %v", err)
+	}
+	if sum.Sum != 5 {
+		t.Errorf("Expected sum 5, got %d", sum.Sum)
+	}
+
+	s, err := jsonrpcReqResp.Call[ConcatParams, string](
+		ctx,
+		caller,
+		"concat",
+		ConcatParams{S1: "Hello, ", S2: "World!"},
+	)
+	if err != nil {
+		t.Fatalf("Call concat returned error: %v", err)
+	}
+	if s != "Hello, World!" {
+		t.Errorf("Expected concatenated string, got %q", s)
+	}
+
+	_, err = jsonrpcReqResp.Call[AddParams, AddResult](ctx, caller, "unknown_method", AddParams{})
+	var jerr *jsonrpcReqResp.JSONRPCError
+	if !errors.As(err, &jerr) || !errors.Is(err, jsonrpcReqResp.MethodNotFoundError) {
+		t.Errorf("Expected method not found error, got %v", err)
+	}
+
+	err = jsonrpcReqResp.Notify(ctx, caller, "notify", NotifyParams{Message: "Hello"})
+	if err != nil {
+		t.Errorf("Notify returned error: %v", err)
+	}
+}
+
+// TestPeerInfo checks the PeerInfo of the messages of caller. Transports without headers, e.g. stdio,
+// must give no headers.
+func TestPeerInfo(t *testing.T, caller jsonrpcReqResp.Caller, wantTransport string, wantHeaders bool) {
+	info, err := jsonrpcReqResp.Call[*struct{}, PeerInfoResult](t.Context(), caller, "peerinfo", nil)
+	if err != nil {
+		t.Fatalf("Call peerinfo returned error: %v", err)
+	}
+	if info.Transport != wantTransport {
+		t.Errorf("Expected transport %q, got %q", wantTransport, info.Transport)
+	}
+	if info.RemoteAddr == "" {
+		t.Errorf("Expected the remote address of the peer")
+	}
+	if wantHeaders != (len(info.HeaderNames) > 0) {
+		t.Errorf("Expected headers: %v, got %q", wantHeaders, info.HeaderNames)
+	}
+}
+
+// TestBatchRequests checks batches. Transports answering asynchronously, e.g. SSE, send nothing for
+// batches of notifications and set emptyReplies.
+func TestBatchRequests(t *testing.T, client JSONRPCClient, emptyReplies bool) {
 	tests := []struct {
 		name               string
 		batchRequest       []any
@@ -440,6 +535,9 @@ func TestBatchRequests(t *testing.T, client JSONRPCClient) {
 			respBody := sendJSONRPCRequest(t, client, batch)
 
 			if tc.expectedResponses == 0 {
+				if emptyReplies && len(respBody) == 0 {
+					return
+				}
 				var o any
 				err := json.Unmarshal(respBody, &o)
 				if err != nil || o != nil {
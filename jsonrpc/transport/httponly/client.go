@@ -0,0 +1,59 @@
+package httponly
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// Client sends JSONRPC messages to a JSONRPC endpoint over HTTP.
+// It implements jsonrpcReqResp.Caller, so it can be used with jsonrpcReqResp.Call and Notify.
+type Client struct {
+	client *http.Client
+	url    string
+}
+
+// NewClient creates a client posting messages to url, which is the full URL of the JSONRPC endpoint.
+// If httpClient is nil, http.DefaultClient is used.
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		client: httpClient,
+		url:    url,
+	}
+}
+
+// Send sends a message and returns the response body.
+func (c *Client) Send(reqBytes []byte) ([]byte, error) {
+	return c.SendContext(context.Background(), reqBytes)
+}
+
+// SendContext sends a message and returns the response body.
+// The response body is empty or null for notifications.
+func (c *Client) SendContext(ctx context.Context, reqBytes []byte) ([]byte, error) {
+	// Create a new HTTP request with context.
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.url,
+		bytes.NewReader(reqBytes),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the content type header.
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform the HTTP request.
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
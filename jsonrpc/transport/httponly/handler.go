@@ -20,3 +20,14 @@ func Register(api huma.API,
 	// Register the methods.
 	humaadapter.Register(api, op, methodMap, notificationMap, nil, nil)
 }
+
+// RegisterRegistry registers the JSONRPC endpoint serving the handlers in registry.
+// Handlers can be added to or removed from the registry while serving.
+// Options, e.g. jsonrpcReqResp.WithCodec, are passed to the BatchRequestHandler of the endpoint.
+func RegisterRegistry(api huma.API, registry *jsonrpcReqResp.Registry, opts ...jsonrpcReqResp.HandlerOption) {
+	// Get default operation.
+	op := humaadapter.GetDefaultOperation()
+	op.Path = JSONRPCEndpoint
+	// Register the methods.
+	humaadapter.RegisterRegistry(api, op, registry, opts...)
+}
@@ -1,58 +1,25 @@
 package httponly
 
 import (
-	"bytes"
-	"context"
-	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	"github.com/ppipada/go-mcp-expt/jsonrpc/humaadapter"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
 	"github.com/ppipada/go-mcp-expt/jsonrpc/transport/helpers_test"
 )
 
-type HTTPJSONRPCClient struct {
-	client *http.Client
-	url    string
-}
-
-func NewHTTPClient(t *testing.T) *HTTPJSONRPCClient {
+func NewHTTPClient(t *testing.T) *Client {
 	handler := SetupHTTPOnlyTransport()
 	server := httptest.NewUnstartedServer(handler)
 	server.Start()
 	// Ensure server closes after test.
 	t.Cleanup(server.Close)
-	client := server.Client()
-	url := server.URL + JSONRPCEndpoint
-	return &HTTPJSONRPCClient{
-		client: client,
-		url:    url,
-	}
-}
-
-func (c *HTTPJSONRPCClient) Send(reqBytes []byte) ([]byte, error) {
-	// Create a new HTTP request with context.
-	req, err := http.NewRequestWithContext(
-		context.Background(),
-		http.MethodPost,
-		c.url,
-		bytes.NewReader(reqBytes),
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	// Set the content type header.
-	req.Header.Set("Content-Type", "application/json")
-
-	// Perform the HTTP request.
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
+	return NewClient(server.URL+JSONRPCEndpoint, server.Client())
 }
 
 func getClient(t *testing.T) helpers_test.JSONRPCClient {
@@ -67,10 +34,45 @@ func TestInvalidSingleRequests(t *testing.T) {
 	helpers_test.TestInvalidSingleRequests(t, getClient(t))
 }
 
+func TestRequestIDEcho(t *testing.T) {
+	helpers_test.TestRequestIDEcho(t, getClient(t))
+}
+
 func TestNotifications(t *testing.T) {
-	helpers_test.TestNotifications(t, getClient(t))
+	helpers_test.TestNotifications(t, getClient(t), false)
 }
 
 func TestBatchRequests(t *testing.T) {
-	helpers_test.TestBatchRequests(t, getClient(t))
+	helpers_test.TestBatchRequests(t, getClient(t), false)
+}
+
+func TestTypedCalls(t *testing.T) {
+	helpers_test.TestTypedCalls(t, NewHTTPClient(t))
+}
+
+func TestPeerInfo(t *testing.T) {
+	helpers_test.TestPeerInfo(t, NewHTTPClient(t), humaadapter.DefaultTransport, true)
+}
+
+func TestRegistryCodec(t *testing.T) {
+	router := http.NewServeMux()
+	api := humago.New(router, huma.DefaultConfig("Example JSONRPC API", "1.0.0"))
+	registry := jsonrpcReqResp.NewRegistry()
+	registry.RegisterMethod("add", &jsonrpcReqResp.MethodHandler[helpers_test.AddParams, helpers_test.AddResult]{
+		Endpoint: helpers_test.AddEndpoint,
+	})
+	RegisterRegistry(api, registry, jsonrpcReqResp.WithCodec(jsonrpcReqResp.StrictJSONCodec{}))
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL+JSONRPCEndpoint, server.Client())
+
+	resp, err := client.Send([]byte(`{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":1}`))
+	if err != nil || !strings.Contains(string(resp), `"result":{"sum":3}`) {
+		t.Fatalf("Expected the sum, got %s, %v", resp, err)
+	}
+	// The body is decoded by huma, which accepts duplicate keys, the params by the codec of the transport.
+	resp, err = client.Send([]byte(`{"jsonrpc":"2.0","method":"add","params":{"a":1,"a":1,"b":2},"id":2}`))
+	if err != nil || !strings.Contains(string(resp), `"code":-32602`) {
+		t.Errorf("Expected duplicate keys to be rejected by the transport codec, got %s, %v", resp, err)
+	}
 }
@@ -0,0 +1,463 @@
+package mcphttpsse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
+)
+
+// ErrClientClosed is returned when sending with a Client whose SSE stream is closed.
+var ErrClientClosed = errors.New("sse stream closed")
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithHandler serves the requests and notifications sent by the server on the SSE stream with handler.
+// Responses are POSTed back to the server. Without a handler, they are dropped.
+// Notifications are handled in order on the stream, so their handlers must not wait for other messages
+// of the server.
+func WithHandler(handler *jsonrpcReqResp.BatchRequestHandler) ClientOption {
+	return func(c *Client) {
+		c.peer = jsonrpcReqResp.NewPeer(handler, nil)
+	}
+}
+
+// Client sends JSONRPC messages over the HTTP with SSE transport.
+// It opens an SSE stream on the first message, POSTs messages to the endpoint of its session, and
+// returns the responses received as message events on the stream, matched by request ID.
+// It implements jsonrpcReqResp.Caller, so it can be used with jsonrpcReqResp.Call and Notify.
+type Client struct {
+	client *http.Client
+	url    string
+	// peer serves the messages sent by the server, if set.
+	peer *jsonrpcReqResp.Peer
+
+	connectMu sync.Mutex
+	endpoint  string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	mu      sync.Mutex
+	err     error
+	pending []*pendingReply
+}
+
+// pendingReply is a message waiting for its response.
+type pendingReply struct {
+	// ids holds the keys of the request IDs of the message.
+	ids map[string]bool
+	// unknownID is set if the message can get a response without ID, e.g. for a parse error.
+	unknownID bool
+	reply     chan []byte
+}
+
+// NewClient creates a client for the SSE stream at url, which is the full URL of the SSE endpoint.
+// If httpClient is nil, http.DefaultClient is used.
+func NewClient(url string, httpClient *http.Client, opts ...ClientOption) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	c := &Client{
+		client: httpClient,
+		url:    url,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Connect opens the SSE stream, which is otherwise opened by the first message. It is needed to get
+// the messages of the server before sending any.
+func (c *Client) Connect(ctx context.Context) error {
+	_, _, err := c.connect(ctx)
+	return err
+}
+
+// Send sends a message and returns the response.
+func (c *Client) Send(reqBytes []byte) ([]byte, error) {
+	return c.SendContext(context.Background(), reqBytes)
+}
+
+// SendContext sends a message and waits for its response on the SSE stream.
+// The response is empty for notifications.
+func (c *Client) SendContext(ctx context.Context, reqBytes []byte) ([]byte, error) {
+	endpoint, done, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending *pendingReply
+	if ids, unknownID := getRequestIDs(reqBytes); len(ids) > 0 || unknownID {
+		pending = &pendingReply{ids: ids, unknownID: unknownID, reply: make(chan []byte, 1)}
+		// Wait before sending, as the response can be sent before the POST is answered.
+		c.mu.Lock()
+		c.pending = append(c.pending, pending)
+		c.mu.Unlock()
+		defer c.removePending(pending)
+	}
+
+	if err := c.post(ctx, endpoint, reqBytes); err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, nil
+	}
+
+	select {
+	case reply := <-pending.reply:
+		return reply, nil
+	case <-done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// post sends a message to the endpoint of the session.
+func (c *Client) post(ctx context.Context, endpoint string, msg []byte) error {
+	// Create a new HTTP request with context.
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		endpoint,
+		bytes.NewReader(msg),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Set the content type header.
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform the HTTP request.
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// Close closes the SSE stream, which ends the session.
+func (c *Client) Close() {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// connect opens the SSE stream if needed, and returns the URL of the endpoint of the session and
+// a channel closed when the stream ends.
+func (c *Client) connect(ctx context.Context) (endpoint string, done <-chan struct{}, err error) {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+	if c.done != nil {
+		select {
+		case <-c.done:
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return "", nil, c.err
+		default:
+			return c.endpoint, c.done, nil
+		}
+	}
+
+	// The stream outlives the context of the first message.
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, c.url, nil)
+	if err != nil {
+		cancel()
+		return "", nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		return "", nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return "", nil, fmt.Errorf("unexpected status %s for the SSE stream", resp.Status)
+	}
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	events := bufio.NewReader(resp.Body)
+	for {
+		event, data, err := readEvent(events)
+		if err != nil {
+			resp.Body.Close()
+			cancel()
+			return "", nil, fmt.Errorf("no endpoint event: %w", err)
+		}
+		if event != EndpointEvent {
+			continue
+		}
+		// The endpoint is sent as a JSON string, or as is.
+		path := string(data)
+		_ = json.Unmarshal(data, &path)
+		base, err := url.Parse(c.url)
+		if err != nil {
+			resp.Body.Close()
+			cancel()
+			return "", nil, err
+		}
+		ref, err := url.Parse(path)
+		if err != nil {
+			resp.Body.Close()
+			cancel()
+			return "", nil, fmt.Errorf("invalid endpoint %q: %w", path, err)
+		}
+		c.endpoint = base.ResolveReference(ref).String()
+		break
+	}
+	if !stop() {
+		// The context of the first message is done.
+		resp.Body.Close()
+		cancel()
+		return "", nil, ctx.Err()
+	}
+
+	c.ctx = streamCtx
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go c.readLoop(events, resp.Body, c.done)
+	return c.endpoint, c.done, nil
+}
+
+// readLoop delivers the message events of the stream, until it ends.
+func (c *Client) readLoop(events *bufio.Reader, body io.Closer, done chan struct{}) {
+	defer body.Close()
+	for {
+		event, data, err := readEvent(events)
+		if err != nil {
+			c.mu.Lock()
+			c.err = fmt.Errorf("%w: %w", ErrClientClosed, err)
+			c.mu.Unlock()
+			close(done)
+			return
+		}
+		if event != MessageEvent {
+			continue
+		}
+		if c.peer != nil && isRequestMessage(data) {
+			if ids, unknownID := getRequestIDs(data); len(ids) == 0 && !unknownID {
+				// Notifications are handled in the order they are sent.
+				c.serve(data)
+			} else {
+				go c.serve(data)
+			}
+			continue
+		}
+		c.deliver(data)
+	}
+}
+
+// serve handles a message sent by the server, and POSTs its response back.
+func (c *Client) serve(msg []byte) {
+	reply, err := c.peer.HandleMessage(c.ctx, msg)
+	if err != nil || reply == nil {
+		return
+	}
+	_ = c.post(c.ctx, c.endpoint, reply)
+}
+
+// deliver hands a message to the pending message with one of its IDs, or to the first one that can get
+// a response without ID. Messages no one waits for are dropped.
+func (c *Client) deliver(msg []byte) {
+	ids, unknownID := getResponseIDs(msg)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index := -1
+	for i, pending := range c.pending {
+		for id := range ids {
+			if pending.ids[id] {
+				index = i
+				break
+			}
+		}
+		if index >= 0 {
+			break
+		}
+	}
+	if index < 0 && unknownID {
+		for i, pending := range c.pending {
+			if pending.unknownID {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 {
+		return
+	}
+	pending := c.pending[index]
+	c.pending = append(c.pending[:index], c.pending[index+1:]...)
+	pending.reply <- msg
+}
+
+func (c *Client) removePending(pending *pendingReply) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, p := range c.pending {
+		if p == pending {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// readEvent reads the next event of an SSE stream. Events without data are skipped.
+func readEvent(r *bufio.Reader) (event string, data []byte, err error) {
+	event = MessageEvent
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if lines != nil {
+				return event, []byte(strings.Join(lines, "\n")), nil
+			}
+			event = MessageEvent
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			lines = append(lines, value)
+		}
+	}
+}
+
+// getRequestIDs returns the keys of the IDs of the requests in a message. unknownID is set if the
+// message can get a response without ID, i.e. if it or one of its items is invalid.
+func getRequestIDs(msg []byte) (ids map[string]bool, unknownID bool) {
+	ids = map[string]bool{}
+	items, ok := splitItems(msg)
+	if !ok {
+		return ids, true
+	}
+	for _, item := range items {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(item, &fields); err != nil || fields == nil {
+			unknownID = true
+			continue
+		}
+		rawID, ok := fields["id"]
+		if !ok {
+			// Notifications get no response.
+			continue
+		}
+		var id jsonrpcReqResp.RequestID
+		if err := json.Unmarshal(rawID, &id); err != nil {
+			unknownID = true
+			continue
+		}
+		ids[id.Key()] = true
+	}
+	return ids, unknownID
+}
+
+// getResponseIDs returns the keys of the IDs of the responses in a message. unknownID is set if one of
+// them has no ID.
+func getResponseIDs(msg []byte) (ids map[string]bool, unknownID bool) {
+	ids = map[string]bool{}
+	items, ok := splitItems(msg)
+	if !ok {
+		return ids, true
+	}
+	for _, item := range items {
+		var resp struct {
+			ID *jsonrpcReqResp.RequestID `json:"id"`
+		}
+		if err := json.Unmarshal(item, &resp); err != nil || resp.ID == nil {
+			unknownID = true
+			continue
+		}
+		ids[resp.ID.Key()] = true
+	}
+	return ids, unknownID
+}
+
+// splitItems returns the items of a batch, or the message itself. It fails for invalid JSON and
+// empty batches.
+func splitItems(msg []byte) ([]json.RawMessage, bool) {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) == 0 || msg[0] != '[' {
+		return []json.RawMessage{msg}, json.Valid(msg)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(msg, &items); err != nil || len(items) == 0 {
+		return nil, false
+	}
+	return items, true
+}
+
+// isRequestMessage reports whether a message holds requests or notifications.
+func isRequestMessage(msg []byte) bool {
+	items, _ := splitItems(msg)
+	for _, item := range items {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(item, &fields) == nil && fields["method"] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// splitResponses splits the responses out of a message. responses holds the responses of the message,
+// as a batch, and rest the other items, as a batch if the message is one. Either is nil if empty.
+func splitResponses(msg []byte) (responses, rest []byte) {
+	items, ok := splitItems(msg)
+	if !ok {
+		return nil, msg
+	}
+	var responseItems, otherItems []json.RawMessage
+	for _, item := range items {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(item, &fields) == nil && fields["method"] == nil &&
+			(fields["result"] != nil || fields["error"] != nil) {
+			responseItems = append(responseItems, item)
+		} else {
+			otherItems = append(otherItems, item)
+		}
+	}
+	switch {
+	case len(responseItems) == 0:
+		return nil, msg
+	case len(otherItems) == 0:
+		return msg, nil
+	}
+	responses, _ = json.Marshal(responseItems)
+	rest, _ = json.Marshal(otherItems)
+	return responses, rest
+}
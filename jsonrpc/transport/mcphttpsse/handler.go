@@ -1,11 +1,21 @@
 package mcphttpsse
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/sse"
@@ -25,33 +35,111 @@ func newUUID() (string, error) {
 const (
 	SSEEndpoint     = "/sse"
 	JSONRPCEndpoint = "/jsonrpc"
+	// SessionIDParam is the query parameter of the JSONRPC endpoint holding the SSE session.
+	SessionIDParam = "sessionId"
+	// Transport is the transport name of the jsonrpcReqResp.PeerInfo of SSE requests.
+	Transport = "sse"
+	// MessageEvent is the SSE event of the JSONRPC messages sent to the client.
+	MessageEvent = "message"
+	// EndpointEvent is the SSE event holding the URL of the JSONRPC endpoint of the session.
+	EndpointEvent = "endpoint"
 )
 
+// defaultMaxBodyBytes is the body limit of the JSONRPC endpoint if the operation does not set one.
+const defaultMaxBodyBytes = 1024 * 1024
+
+// DefaultMaxSessionMessages is the number of messages a session handles at a time, if
+// SSETransport.MaxSessionMessages is not set.
+const DefaultMaxSessionMessages = 16
+
+var (
+	// ErrSessionClosed is returned when sending to an SSE session that is closed.
+	ErrSessionClosed = errors.New("session closed")
+	// ErrUnknownSession is returned when sending to an SSE session that does not exist.
+	ErrUnknownSession = errors.New("unknown session")
+)
+
+// session is the SSE connection of a client.
+type session struct {
+	// ctx is done when the client disconnects.
+	ctx context.Context
+	// peer makes the calls to the client, whose responses are POSTed by the client.
+	peer *jsonrpcReqResp.Peer
+	// inFlight holds a slot per message being handled.
+	inFlight chan struct{}
+	mu       sync.Mutex
+	send     sse.Sender
+	closed   bool
+}
+
+// sendMessage sends a JSONRPC message to the client as a MessageEvent.
+// The message is written as raw JSON by the encoder of the sse package.
+func (s *session) sendMessage(msg json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSessionClosed
+	}
+	return s.send.Data(msg)
+}
+
+func (s *session) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.peer.Close()
+}
+
 // SSETransport manages SSE connections and messages.
+//
+// It follows the HTTP with SSE transport of MCP: clients open an SSE stream at SSEEndpoint and get an
+// EndpointEvent with the URL to POST their messages to, which holds their session ID. POSTs are
+// answered with 202 Accepted and their responses, including batches, are sent as MessageEvent on the
+// SSE stream of the session. POSTs with an unknown session get 404 Not Found.
+//
+// The server can also send notifications and requests to the clients, with Notify, Broadcast and Call.
 type SSETransport struct {
-	sessionMap map[string]sse.Sender
+	// MaxSessionMessages is the number of messages of a session handled at a time, further POSTs of
+	// the session wait before being accepted. Zero uses DefaultMaxSessionMessages.
+	MaxSessionMessages int
+	// Codec decodes the params and encodes the results of the handlers, and those of the calls made
+	// to the clients. Nil uses jsonrpcReqResp.DefaultCodec.
+	Codec jsonrpcReqResp.Codec
+
+	sessionMap map[string]*session
 	mu         sync.Mutex
 }
 
 // NewSSETransport creates a new SSE server transport.
 func NewSSETransport(endpoint string) *SSETransport {
 	return &SSETransport{
-		sessionMap: make(map[string]sse.Sender),
+		sessionMap: make(map[string]*session),
 	}
 }
 
-// RegisterRoutes registers the SSE and POST message handlers with the Huma API.
+// Register registers the SSE and POST message handlers with the Huma API.
 func (s *SSETransport) Register(
 	api huma.API,
 	methodMap map[string]jsonrpcReqResp.IMethodHandler,
 	notificationMap map[string]jsonrpcReqResp.INotificationHandler,
 ) {
+	registry := jsonrpcReqResp.NewRegistry()
+	for name, handler := range methodMap {
+		registry.RegisterMethod(name, handler)
+	}
+	for name, handler := range notificationMap {
+		registry.RegisterNotification(name, handler)
+	}
+	s.RegisterRegistry(api, registry)
+}
+
+// RegisterRegistry registers the SSE and POST message handlers serving the handlers in registry.
+// Handlers can be added to or removed from the registry while serving.
+func (s *SSETransport) RegisterRegistry(api huma.API, registry *jsonrpcReqResp.Registry) {
 	// Define the mapping between event names and message types.
 	messageTypes := map[string]any{
-		"message": jsonrpcReqResp.BatchRequest{
-			Body: &jsonrpcReqResp.BatchItem[jsonrpcReqResp.UnionRequest]{},
-		},
-		"endpoint": "", // For the initial endpoint event.
+		MessageEvent:  json.RawMessage{},
+		EndpointEvent: "", // For the initial endpoint event.
 	}
 
 	// Register the SSE endpoint.
@@ -65,8 +153,311 @@ func (s *SSETransport) Register(
 	// Get default operation.
 	op := humaadapter.GetDefaultOperation()
 	op.Path = JSONRPCEndpoint
+	op.DefaultStatus = http.StatusAccepted
+	op.Description = "Accept the JSONRPC messages of an SSE session. The POST gets no JSONRPC response: " +
+		"the responses, including batches and errors, arrive as `" + MessageEvent +
+		"` events on the SSE stream of the session."
+	op.Parameters = []*huma.Param{{
+		Name:        SessionIDParam,
+		In:          "query",
+		Required:    true,
+		Description: "Session ID, from the endpoint event of the SSE stream",
+		Schema:      &huma.Schema{Type: huma.TypeString},
+	}}
+	op.Responses = map[string]*huma.Response{
+		"202": {Description: "Accepted, the responses arrive as `" + MessageEvent + "` events on the SSE stream of the session"},
+		"404": {Description: "Unknown session"},
+		"413": {Description: "Body too large"},
+	}
+	op.Middlewares = huma.Middlewares{s.sessionMiddleware(op.MaxBodyBytes)}
 	// Register the methods.
-	humaadapter.Register(api, op, methodMap, notificationMap, nil, nil)
+	humaadapter.RegisterRegistryAsync(api, op, registry, jsonrpcReqResp.WithCodec(s.Codec))
+}
+
+// Notify sends a notification to the client of a session.
+func (s *SSETransport) Notify(ctx context.Context, sessionID, method string, params any) error {
+	sess, err := s.getSession(sessionID)
+	if err != nil {
+		return err
+	}
+	return sess.peer.Notify(ctx, method, params)
+}
+
+// Broadcast sends a notification to the clients of all sessions.
+// It returns the errors of the sessions it could not be sent to, joined.
+func (s *SSETransport) Broadcast(ctx context.Context, method string, params any) error {
+	s.mu.Lock()
+	sessions := make([]*session, 0, len(s.sessionMap))
+	for _, sess := range s.sessionMap {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, sess := range sessions {
+		if err := sess.peer.Notify(ctx, method, params); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Call sends a request to the client of a session, and waits for the response the client POSTs.
+// If result is not nil, the result of the response is decoded into it. An error response is returned
+// as a *jsonrpcReqResp.JSONRPCError, and calls pending when the client disconnects fail with
+// jsonrpcReqResp.ErrPeerClosed.
+//
+// Example:
+//
+//	// In a handler of a message of the session.
+//	sessionID, _ := jsonrpcReqResp.GetSessionID(ctx)
+//	var roots ListRootsResult
+//	err := transport.Call(ctx, sessionID, "roots/list", nil, &roots)
+func (s *SSETransport) Call(ctx context.Context, sessionID, method string, params, result any) error {
+	sess, err := s.getSession(sessionID)
+	if err != nil {
+		return err
+	}
+	return sess.peer.Call(ctx, method, params, result)
+}
+
+func (s *SSETransport) getSession(sessionID string) (*session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessionMap[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSession, sessionID)
+	}
+	return sess, nil
+}
+
+// sessionMiddleware returns the middleware of the JSONRPC endpoint: it answers with 202 Accepted and
+// handles the message asynchronously, sending its response on the SSE stream of the session.
+// Responses to the calls of the server, including those in a batch with requests, are given to the peer
+// of the session. Bodies larger than maxBytes get 413 Request Entity Too Large.
+// Each session handles up to MaxSessionMessages messages at a time, further POSTs wait for a slot
+// before being answered.
+func (s *SSETransport) sessionMiddleware(maxBytes int64) func(huma.Context, func(huma.Context)) {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBodyBytes
+	}
+	return func(ctx huma.Context, next func(huma.Context)) {
+		sessionID := ctx.Query(SessionIDParam)
+		sess, err := s.getSession(sessionID)
+		if err != nil {
+			writeStatus(ctx, http.StatusNotFound, "Unknown session "+sessionID)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(ctx.BodyReader(), maxBytes+1))
+		if err != nil {
+			writeStatus(ctx, http.StatusBadRequest, "Cannot read body: "+err.Error())
+			return
+		}
+		if int64(len(body)) > maxBytes {
+			writeStatus(ctx, http.StatusRequestEntityTooLarge, fmt.Sprintf("Body exceeds %d bytes", maxBytes))
+			return
+		}
+		responses, body := splitResponses(body)
+		if responses != nil {
+			// Responses to the calls of the server.
+			_, _ = sess.peer.HandleMessage(ctx.Context(), responses)
+		}
+		if body == nil {
+			ctx.SetStatus(http.StatusAccepted)
+			return
+		}
+
+		select {
+		case sess.inFlight <- struct{}{}:
+		case <-sess.ctx.Done():
+			writeStatus(ctx, http.StatusNotFound, "Unknown session "+sessionID)
+			return
+		case <-ctx.Context().Done():
+			return
+		}
+
+		info, _ := jsonrpcReqResp.GetPeerInfo(ctx.Context())
+		info.Transport = Transport
+		info.SessionID = sessionID
+		// The message outlives the POST, it is canceled when the client disconnects instead.
+		msgCtx, cancel := context.WithCancel(
+			jsonrpcReqResp.ContextWithPeerInfo(context.WithoutCancel(ctx.Context()), info),
+		)
+		stop := context.AfterFunc(sess.ctx, cancel)
+		w := newSessionContext(ctx, msgCtx, body)
+		ctx.SetStatus(http.StatusAccepted)
+
+		go func() {
+			defer func() { <-sess.inFlight }()
+			defer cancel()
+			defer stop()
+			defer func() {
+				// Like net/http, a panic fails the message instead of the server.
+				if r := recover(); r != nil {
+					log.Printf("Panic handling message of SSE session %s: %v\n%s", sessionID, r, debug.Stack())
+				}
+			}()
+			next(w)
+			reply := bytes.TrimSpace(w.out.Bytes())
+			if len(reply) == 0 || bytes.Equal(reply, []byte("null")) {
+				// Notifications get no response.
+				return
+			}
+			if !json.Valid(reply) {
+				log.Printf("Invalid response for SSE session %s: %s", sessionID, reply)
+				return
+			}
+			if err := sess.sendMessage(reply); err != nil {
+				log.Printf("Cannot send response to SSE session %s: %v", sessionID, err)
+			}
+		}()
+	}
+}
+
+// writeStatus writes a plain text response, for errors happening before the message is accepted.
+func writeStatus(ctx huma.Context, status int, message string) {
+	ctx.SetHeader("Content-Type", "text/plain; charset=utf-8")
+	ctx.SetStatus(status)
+	_, _ = ctx.BodyWriter().Write([]byte(message + "\n"))
+}
+
+// sessionContext is the context of a message handled after its POST was answered. It holds a copy of
+// the request, as the context of the POST cannot be used once answered, replays the body read before,
+// and records the response instead of writing it.
+type sessionContext struct {
+	ctx        context.Context
+	op         *huma.Operation
+	tls        *tls.ConnectionState
+	version    huma.ProtoVersion
+	method     string
+	host       string
+	remoteAddr string
+	url        url.URL
+	reqHeader  http.Header
+	body       io.Reader
+
+	header http.Header
+	status int
+	out    bytes.Buffer
+}
+
+// newSessionContext copies the request of ctx, with msgCtx as context and body as body.
+func newSessionContext(ctx huma.Context, msgCtx context.Context, body []byte) *sessionContext {
+	c := &sessionContext{
+		ctx:        msgCtx,
+		op:         ctx.Operation(),
+		version:    ctx.Version(),
+		method:     ctx.Method(),
+		host:       ctx.Host(),
+		remoteAddr: ctx.RemoteAddr(),
+		url:        ctx.URL(),
+		reqHeader:  http.Header{},
+		body:       bytes.NewReader(body),
+		header:     http.Header{},
+		status:     http.StatusOK,
+	}
+	if state := ctx.TLS(); state != nil {
+		tlsState := *state
+		c.tls = &tlsState
+	}
+	if c.url.User != nil {
+		user := *c.url.User
+		c.url.User = &user
+	}
+	ctx.EachHeader(func(name, value string) {
+		c.reqHeader.Add(name, value)
+	})
+	return c
+}
+
+func (c *sessionContext) Operation() *huma.Operation {
+	return c.op
+}
+
+func (c *sessionContext) Context() context.Context {
+	return c.ctx
+}
+
+func (c *sessionContext) TLS() *tls.ConnectionState {
+	return c.tls
+}
+
+func (c *sessionContext) Version() huma.ProtoVersion {
+	return c.version
+}
+
+func (c *sessionContext) Method() string {
+	return c.method
+}
+
+func (c *sessionContext) Host() string {
+	return c.host
+}
+
+func (c *sessionContext) RemoteAddr() string {
+	return c.remoteAddr
+}
+
+func (c *sessionContext) URL() url.URL {
+	return c.url
+}
+
+// Param returns no path params, as the JSONRPC endpoint has none.
+func (c *sessionContext) Param(string) string {
+	return ""
+}
+
+func (c *sessionContext) Query(name string) string {
+	return c.url.Query().Get(name)
+}
+
+func (c *sessionContext) Header(name string) string {
+	return c.reqHeader.Get(name)
+}
+
+func (c *sessionContext) EachHeader(cb func(name, value string)) {
+	for name, values := range c.reqHeader {
+		for _, value := range values {
+			cb(name, value)
+		}
+	}
+}
+
+func (c *sessionContext) BodyReader() io.Reader {
+	return c.body
+}
+
+func (c *sessionContext) GetMultipartForm() (*multipart.Form, error) {
+	return nil, errors.New("multipart forms are not supported by JSONRPC messages")
+}
+
+func (c *sessionContext) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (c *sessionContext) SetStatus(status int) {
+	c.status = status
+}
+
+func (c *sessionContext) Status() int {
+	return c.status
+}
+
+func (c *sessionContext) SetHeader(name, value string) {
+	c.header.Set(name, value)
+}
+
+func (c *sessionContext) AppendHeader(name, value string) {
+	c.header.Add(name, value)
+}
+
+func (c *sessionContext) BodyWriter() io.Writer {
+	return &c.out
+}
+
+// newHandler returns the handler of the responses of the clients to the calls of a session.
+func (s *SSETransport) newHandler() *jsonrpcReqResp.BatchRequestHandler {
+	return jsonrpcReqResp.NewBatchRequestHandler(jsonrpcReqResp.WithCodec(s.Codec))
 }
 
 // handleSSEConnection handles the initial SSE connection request.
@@ -83,9 +474,17 @@ func (s *SSETransport) handleSSEConnection(
 	}
 	sessionID := u
 
-	// Store the sender associated with the session ID.
+	// Store the session, so that the responses of its messages are sent with send.
+	sess := &session{
+		ctx:      ctx,
+		send:     send,
+		inFlight: make(chan struct{}, cmp.Or(s.MaxSessionMessages, DefaultMaxSessionMessages)),
+	}
+	sess.peer = jsonrpcReqResp.NewPeer(s.newHandler(), func(ctx context.Context, msg []byte) error {
+		return sess.sendMessage(msg)
+	})
 	s.mu.Lock()
-	s.sessionMap[sessionID] = send
+	s.sessionMap[sessionID] = sess
 	s.mu.Unlock()
 
 	// Log the new connection.
@@ -93,16 +492,16 @@ func (s *SSETransport) handleSSEConnection(
 
 	// Send the endpoint event to the client with the session ID.
 	// The event is deduced from value type in the handler.
-	err = send.Data(fmt.Sprintf("%s?sessionId=%s", JSONRPCEndpoint, sessionID))
-	if err != nil {
-		// TODO: Write error and close.
-		return
+	sess.mu.Lock()
+	err = send.Data(fmt.Sprintf("%s?%s=%s", JSONRPCEndpoint, SessionIDParam, sessionID))
+	sess.mu.Unlock()
+	if err == nil {
+		// Wait until the context is done (client disconnects).
+		<-ctx.Done()
 	}
 
-	// Wait until the context is done (client disconnects).
-	<-ctx.Done()
-
 	// Clean up when the client disconnects.
+	sess.close()
 	s.mu.Lock()
 	delete(s.sessionMap, sessionID)
 	s.mu.Unlock()
@@ -1,58 +1,32 @@
 package mcphttpsse
 
 import (
-	"bytes"
 	"context"
-	"io"
+	"encoding/json"
+	"errors"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	jsonrpcReqResp "github.com/ppipada/go-mcp-expt/jsonrpc/reqresp"
 	"github.com/ppipada/go-mcp-expt/jsonrpc/transport/helpers_test"
 )
 
-type HTTPJSONRPCClient struct {
-	client *http.Client
-	url    string
-}
-
-func NewHTTPClient(t *testing.T) *HTTPJSONRPCClient {
+func NewHTTPClient(t *testing.T) *Client {
 	handler := SetupSSETransport()
 	server := httptest.NewUnstartedServer(handler)
 	server.Start()
 	// Ensure server closes after test.
 	t.Cleanup(server.Close)
-	client := server.Client()
-	url := server.URL + JSONRPCEndpoint
-	return &HTTPJSONRPCClient{
-		client: client,
-		url:    url,
-	}
-}
-
-func (c *HTTPJSONRPCClient) Send(reqBytes []byte) ([]byte, error) {
-	// Create a new HTTP request with context.
-	req, err := http.NewRequestWithContext(
-		context.Background(),
-		http.MethodPost,
-		c.url,
-		bytes.NewReader(reqBytes),
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	// Set the content type header.
-	req.Header.Set("Content-Type", "application/json")
-
-	// Perform the HTTP request.
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
+	client := NewClient(server.URL+SSEEndpoint, server.Client())
+	t.Cleanup(client.Close)
+	return client
 }
 
 func getClient(t *testing.T) helpers_test.JSONRPCClient {
@@ -67,10 +41,301 @@ func TestInvalidSingleRequests(t *testing.T) {
 	helpers_test.TestInvalidSingleRequests(t, getClient(t))
 }
 
+func TestRequestIDEcho(t *testing.T) {
+	helpers_test.TestRequestIDEcho(t, getClient(t))
+}
+
 func TestNotifications(t *testing.T) {
-	helpers_test.TestNotifications(t, getClient(t))
+	helpers_test.TestNotifications(t, getClient(t), true)
 }
 
 func TestBatchRequests(t *testing.T) {
-	helpers_test.TestBatchRequests(t, getClient(t))
+	helpers_test.TestBatchRequests(t, getClient(t), true)
+}
+
+func TestTypedCalls(t *testing.T) {
+	helpers_test.TestTypedCalls(t, NewHTTPClient(t))
+}
+
+func TestPeerInfo(t *testing.T) {
+	helpers_test.TestPeerInfo(t, NewHTTPClient(t), Transport, true)
+}
+
+func TestSessionRouting(t *testing.T) {
+	server := httptest.NewServer(SetupSSETransport())
+	t.Cleanup(server.Close)
+
+	for _, endpoint := range []string{JSONRPCEndpoint, JSONRPCEndpoint + "?" + SessionIDParam + "=unknown"} {
+		resp, err := server.Client().Post(
+			server.URL+endpoint,
+			"application/json",
+			strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a": 1, "b": 2}}`),
+		)
+		if err != nil {
+			t.Fatalf("POST %s returned error: %v", endpoint, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusNotFound, endpoint, resp.StatusCode)
+		}
+	}
+
+	// Responses, including batches, are sent on the stream of the session.
+	client := NewClient(server.URL+SSEEndpoint, server.Client())
+	t.Cleanup(client.Close)
+	reply, err := client.Send([]byte(`[{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"a": 1, "b": 2}},` +
+		`{"jsonrpc": "2.0", "method": "notify", "params": {"message": "Hello"}},` +
+		`{"jsonrpc": "2.0", "id": "b", "method": "concat", "params": {"s1": "a", "s2": "b"}}]`))
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	var responses []jsonrpcReqResp.Response[json.RawMessage]
+	if err := json.Unmarshal(reply, &responses); err != nil || len(responses) != 2 {
+		t.Fatalf("Expected a batch of 2 responses, got %s", reply)
+	}
+
+	info, err := jsonrpcReqResp.Call[*struct{}, helpers_test.PeerInfoResult](t.Context(), client, "peerinfo", nil)
+	if err != nil || info.Transport != Transport {
+		t.Errorf("Unexpected peer info %+v: %v", info, err)
+	}
+
+	// Bodies over the limit are rejected before being accepted.
+	endpoint, _, err := client.connect(t.Context())
+	if err != nil {
+		t.Fatalf("connect returned error: %v", err)
+	}
+	for _, body := range []string{
+		`{"jsonrpc": "2.0", "method": "notify", "params": {"message": "` + strings.Repeat("a", defaultMaxBodyBytes) + `"}}`,
+		`{"jsonrpc": "2.0", "id": 1, "result": "` + strings.Repeat("a", defaultMaxBodyBytes) + `"}`,
+	} {
+		resp, err := server.Client().Post(endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST returned error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, resp.StatusCode)
+		}
+	}
+}
+
+type RootsResult struct {
+	Roots []string `json:"roots"`
+}
+
+func TestServerMessages(t *testing.T) {
+	router := http.NewServeMux()
+	api := humago.New(router, huma.DefaultConfig("Example JSONRPC API", "1.0.0"))
+	transport := NewSSETransport(JSONRPCEndpoint)
+	transport.Register(api, map[string]jsonrpcReqResp.IMethodHandler{
+		"session": &jsonrpcReqResp.MethodHandler[*struct{}, string]{
+			Endpoint: func(ctx context.Context, _ *struct{}) (string, error) {
+				sessionID, _ := jsonrpcReqResp.GetSessionID(ctx)
+				return sessionID, nil
+			},
+		},
+	}, nil)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	notified := make(chan string, 2)
+	clientHandler := jsonrpcReqResp.NewBatchRequestHandler(
+		jsonrpcReqResp.WithMethodMap(map[string]jsonrpcReqResp.IMethodHandler{
+			"roots/list": &jsonrpcReqResp.MethodHandler[*struct{}, RootsResult]{
+				Endpoint: func(ctx context.Context, _ *struct{}) (RootsResult, error) {
+					return RootsResult{Roots: []string{"file:///tmp"}}, nil
+				},
+			},
+		}),
+		jsonrpcReqResp.WithNotificationMap(map[string]jsonrpcReqResp.INotificationHandler{
+			"notifications/message": &jsonrpcReqResp.NotificationHandler[helpers_test.NotifyParams]{
+				Endpoint: func(ctx context.Context, params helpers_test.NotifyParams) error {
+					notified <- params.Message
+					return nil
+				},
+			},
+		}),
+	)
+	client := NewClient(server.URL+SSEEndpoint, server.Client(), WithHandler(clientHandler))
+	t.Cleanup(client.Close)
+
+	ctx := t.Context()
+	sessionID, err := jsonrpcReqResp.Call[*struct{}, string](ctx, client, "session", nil)
+	if err != nil || sessionID == "" {
+		t.Fatalf("Expected the session ID, got %q: %v", sessionID, err)
+	}
+
+	var roots RootsResult
+	if err := transport.Call(ctx, sessionID, "roots/list", nil, &roots); err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if len(roots.Roots) != 1 || roots.Roots[0] != "file:///tmp" {
+		t.Errorf("Unexpected result %+v", roots)
+	}
+	err = transport.Call(ctx, sessionID, "unknown", nil, nil)
+	if !errors.Is(err, jsonrpcReqResp.MethodNotFoundError) {
+		t.Errorf("Expected method not found error, got %v", err)
+	}
+
+	if err := transport.Notify(ctx, sessionID, "notifications/message", helpers_test.NotifyParams{Message: "one"}); err != nil {
+		t.Fatalf("Notify returned error: %v", err)
+	}
+	if err := transport.Broadcast(ctx, "notifications/message", helpers_test.NotifyParams{Message: "all"}); err != nil {
+		t.Fatalf("Broadcast returned error: %v", err)
+	}
+	for _, want := range []string{"one", "all"} {
+		select {
+		case got := <-notified:
+			if got != want {
+				t.Errorf("Expected notification %q, got %q", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Notification %q not received", want)
+		}
+	}
+
+	if err := transport.Notify(ctx, "unknown", "notifications/message", nil); !errors.Is(err, ErrUnknownSession) {
+		t.Errorf("Expected unknown session error, got %v", err)
+	}
+}
+
+func TestSessionMessageLimit(t *testing.T) {
+	router := http.NewServeMux()
+	api := humago.New(router, huma.DefaultConfig("Example JSONRPC API", "1.0.0"))
+	transport := NewSSETransport(JSONRPCEndpoint)
+	transport.MaxSessionMessages = 1
+	started, release := make(chan struct{}), make(chan struct{})
+	transport.Register(api, map[string]jsonrpcReqResp.IMethodHandler{
+		"block": &jsonrpcReqResp.MethodHandler[*struct{}, string]{
+			Endpoint: func(ctx context.Context, _ *struct{}) (string, error) {
+				close(started)
+				<-release
+				return "released", nil
+			},
+		},
+		"echo": &jsonrpcReqResp.MethodHandler[*struct{}, string]{
+			Endpoint: func(ctx context.Context, _ *struct{}) (string, error) {
+				return "echo", nil
+			},
+		},
+	}, nil)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL+SSEEndpoint, server.Client())
+	t.Cleanup(client.Close)
+
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := jsonrpcReqResp.Call[*struct{}, string](t.Context(), client, "block", nil)
+		blocked <- err
+	}()
+	<-started
+
+	// The session already handles a message, so the next one waits.
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := jsonrpcReqResp.Call[*struct{}, string](ctx, client, "echo", nil); err == nil {
+		t.Errorf("Expected the message to wait for the running one")
+	}
+
+	close(release)
+	if err := <-blocked; err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	result, err := jsonrpcReqResp.Call[*struct{}, string](t.Context(), client, "echo", nil)
+	if err != nil || result != "echo" {
+		t.Errorf("Expected the message to be handled once the session is free, got %q: %v", result, err)
+	}
+}
+
+func TestMessageOperationDoc(t *testing.T) {
+	router := http.NewServeMux()
+	api := humago.New(router, huma.DefaultConfig("Example JSONRPC API", "1.0.0"))
+	NewSSETransport(JSONRPCEndpoint).Register(api, helpers_test.GetMethodHandlers(), nil)
+
+	op := api.OpenAPI().Paths[JSONRPCEndpoint].Post
+	if op.DefaultStatus != http.StatusAccepted {
+		t.Errorf("Expected default status %d, got %d", http.StatusAccepted, op.DefaultStatus)
+	}
+	// The responses are sent on the SSE stream, so the POST documents none.
+	if keys := slices.Sorted(maps.Keys(op.Responses)); !slices.Equal(keys, []string{"202", "404", "413"}) {
+		t.Errorf("Expected the 202, 404 and 413 responses only, got %v", keys)
+	}
+	if len(op.Responses["202"].Content) > 0 {
+		t.Errorf("Expected no body for 202")
+	}
+	if !strings.Contains(op.Description, "`"+MessageEvent+"` events") {
+		t.Errorf("Expected the description to mention %s events, got %q", MessageEvent, op.Description)
+	}
+	if examples := op.RequestBody.Content["application/json"].Examples; examples["add"] == nil {
+		t.Errorf("Expected the request examples")
+	}
+}
+
+func TestMixedBatch(t *testing.T) {
+	router := http.NewServeMux()
+	api := humago.New(router, huma.DefaultConfig("Example JSONRPC API", "1.0.0"))
+	transport := NewSSETransport(JSONRPCEndpoint)
+	transport.Register(api, map[string]jsonrpcReqResp.IMethodHandler{
+		"session": &jsonrpcReqResp.MethodHandler[*struct{}, string]{
+			Endpoint: func(ctx context.Context, _ *struct{}) (string, error) {
+				sessionID, _ := jsonrpcReqResp.GetSessionID(ctx)
+				return sessionID, nil
+			},
+		},
+	}, nil)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	// The client answers the call of the server in a batch with a request of its own, instead of
+	// answering it alone.
+	callIDs, release := make(chan jsonrpcReqResp.RequestID, 1), make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	clientHandler := jsonrpcReqResp.NewBatchRequestHandler(
+		jsonrpcReqResp.WithMethodMap(map[string]jsonrpcReqResp.IMethodHandler{
+			"roots/list": &jsonrpcReqResp.MethodHandler[*struct{}, RootsResult]{
+				Endpoint: func(ctx context.Context, _ *struct{}) (RootsResult, error) {
+					id, _ := jsonrpcReqResp.GetRequestID(ctx)
+					callIDs <- id
+					<-release
+					return RootsResult{}, errors.New("answered in a batch")
+				},
+			},
+		}),
+	)
+	client := NewClient(server.URL+SSEEndpoint, server.Client(), WithHandler(clientHandler))
+	t.Cleanup(client.Close)
+
+	ctx := t.Context()
+	sessionID, err := jsonrpcReqResp.Call[*struct{}, string](ctx, client, "session", nil)
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	called := make(chan error, 1)
+	var roots RootsResult
+	go func() {
+		called <- transport.Call(ctx, sessionID, "roots/list", nil, &roots)
+	}()
+	id, err := json.Marshal(<-callIDs)
+	if err != nil {
+		t.Fatalf("Invalid request ID: %v", err)
+	}
+
+	reply, err := client.Send([]byte(`[{"jsonrpc": "2.0", "id": ` + string(id) + `, "result": {"roots": ["file:///batch"]}},` +
+		`{"jsonrpc": "2.0", "id": "s", "method": "session"}]`))
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	var responses []jsonrpcReqResp.Response[string]
+	if err := json.Unmarshal(reply, &responses); err != nil || len(responses) != 1 || responses[0].Result != sessionID {
+		t.Errorf("Expected a batch with the response of the request only, got %s", reply)
+	}
+	select {
+	case err := <-called:
+		if err != nil || len(roots.Roots) != 1 || roots.Roots[0] != "file:///batch" {
+			t.Errorf("Unexpected result %+v: %v", roots, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The call of the server did not get the response of the batch")
+	}
 }
@@ -1,7 +1,9 @@
 package mcpstdio
 
 import (
+	"context"
 	"io"
+	"net"
 	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -10,7 +12,11 @@ import (
 	stdioNet "github.com/ppipada/go-mcp-expt/jsonrpc/transport/mcpstdio/net"
 )
 
-const JSONRPCEndpoint = "/jsonrpc"
+const (
+	JSONRPCEndpoint = "/jsonrpc"
+	// Transport is the transport name of the jsonrpcReqResp.PeerInfo of stdio requests.
+	Transport = "stdio"
+)
 
 func Register(api huma.API,
 	methodMap map[string]jsonrpcReqResp.IMethodHandler,
@@ -23,6 +29,17 @@ func Register(api huma.API,
 	humaadapter.Register(api, op, methodMap, notificationMap, nil, nil)
 }
 
+// RegisterRegistry registers the JSONRPC endpoint serving the handlers in registry.
+// Handlers can be added to or removed from the registry while serving.
+// Options, e.g. jsonrpcReqResp.WithCodec, are passed to the BatchRequestHandler of the endpoint.
+func RegisterRegistry(api huma.API, registry *jsonrpcReqResp.Registry, opts ...jsonrpcReqResp.HandlerOption) {
+	// Get default operation.
+	op := humaadapter.GetDefaultOperation()
+	op.Path = JSONRPCEndpoint
+	// Register the methods.
+	humaadapter.RegisterRegistry(api, op, registry, opts...)
+}
+
 // For actual runs os.Stdin, os.Stdout can be passed as reader and writer respectively.
 func GetServer(r io.Reader, w io.Writer, handler http.Handler) *stdioNet.Server {
 	// Create the MessageFramer.
@@ -32,14 +49,26 @@ func GetServer(r io.Reader, w io.Writer, handler http.Handler) *stdioNet.Server
 	requestParams := RequestParams{
 		Method: http.MethodPost,
 		URL:    JSONRPCEndpoint,
-		Header: make(http.Header),
+		Header: http.Header{"Content-Type": {"application/json"}},
 	}
 	messageHandler := NewHTTPMessageHandler(handler, requestParams)
 	stdconn := stdioNet.NewStdioConn(r, w)
 	server := stdioNet.NewServer(stdconn, framer, messageHandler)
+	server.ConnContext = connContext
 	return server
 }
 
+// connContext sets the address of the connection in the jsonrpcReqResp.PeerInfo of its requests.
+// Stdio has no headers: the Header is set empty, so that the headers of the HTTP request built for a
+// message are not taken as those of the peer.
+func connContext(ctx context.Context, c net.Conn) context.Context {
+	info := jsonrpcReqResp.PeerInfo{Transport: Transport, Header: http.Header{}}
+	if addr := c.RemoteAddr(); addr != nil {
+		info.RemoteAddr = addr.String()
+	}
+	return jsonrpcReqResp.ContextWithPeerInfo(ctx, info)
+}
+
 // For actual runs os.Stdout and os.Stdin can be passed as reader and writer respectively.
 func GetClient(r io.Reader, w io.Writer) *stdioNet.Client {
 	framer := &stdioNet.LineFramer{}
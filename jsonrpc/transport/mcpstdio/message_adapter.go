@@ -80,6 +80,11 @@ func NewHTTPMessageHandler(handler http.Handler, params RequestParams) *HTTPMess
 
 // HandleMessage processes a single message.
 func (h *HTTPMessageHandler) HandleMessage(writer io.Writer, msg []byte) {
+	h.HandleMessageContext(context.Background(), writer, msg)
+}
+
+// HandleMessageContext processes a single message, with ctx as the context of its request.
+func (h *HTTPMessageHandler) HandleMessageContext(ctx context.Context, writer io.Writer, msg []byte) {
 	// Log.Printf("MSG: %s", string(msg))
 	// Create a ResponseWriter for this handler.
 	w := &ResponseWriter{
@@ -89,7 +94,7 @@ func (h *HTTPMessageHandler) HandleMessage(writer io.Writer, msg []byte) {
 
 	// Create Request with the message as the body.
 	req, err := http.NewRequestWithContext(
-		context.Background(),
+		ctx,
 		h.RequestParams.Method,
 		h.RequestParams.URL,
 		bytes.NewReader(msg),
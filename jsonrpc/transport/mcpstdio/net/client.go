@@ -2,6 +2,7 @@ package net
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"io"
 	"net"
@@ -123,6 +124,15 @@ func (c *Client) writeMessage(msg []byte) error {
 // If the assignID function returns a nil request ID, the request is sent but the response is not tracked.
 // If concurrency is disabled, Send operates synchronously.
 func (c *Client) Send(msg []byte) ([]byte, error) {
+	return c.SendContext(context.Background(), msg)
+}
+
+// SendContext is like Send, but stops waiting for the response when ctx is done.
+// If concurrency is disabled, ctx is only checked before the message is sent.
+func (c *Client) SendContext(ctx context.Context, msg []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if !c.concurrencyEnabled {
 		err := c.writeMessage(msg)
 		if err != nil {
@@ -171,6 +181,11 @@ func (c *Client) Send(msg []byte) ([]byte, error) {
 		return resp, nil
 	case <-c.done:
 		return nil, errors.New("client closed")
+	case <-ctx.Done():
+		c.pendingMu.Lock()
+		delete(c.pending, reqID)
+		c.pendingMu.Unlock()
+		return nil, ctx.Err()
 	case <-time.After(c.requestTimeout):
 		// Timeout
 		// Clean up pending.
@@ -17,8 +17,19 @@ type MessageHandler interface {
 	HandleMessage(writer io.Writer, msg []byte)
 }
 
+// ContextMessageHandler is a MessageHandler receiving the context of the connection of the message.
+// Servers call HandleMessageContext instead of HandleMessage for handlers implementing it.
+type ContextMessageHandler interface {
+	MessageHandler
+	HandleMessageContext(ctx context.Context, writer io.Writer, msg []byte)
+}
+
 // Server orchestrates the transport, framing, and message handling.
 type Server struct {
+	// ConnContext optionally modifies the context of the messages of a connection, e.g. to add
+	// information about the connection. It is like http.Server.ConnContext.
+	ConnContext func(ctx context.Context, c net.Conn) context.Context
+
 	conn     net.Conn
 	framer   MessageFramer
 	handler  MessageHandler
@@ -57,6 +68,10 @@ func (s *Server) handleConnection(conn net.Conn) {
 	reader := bufio.NewReader(conn)
 	writer := bufio.NewWriter(conn)
 	var writeMutex sync.Mutex
+	ctx := context.Background()
+	if s.ConnContext != nil {
+		ctx = s.ConnContext(ctx, conn)
+	}
 
 	for {
 		select {
@@ -90,7 +105,11 @@ func (s *Server) handleConnection(conn net.Conn) {
 			// Create a buffer to collect the handler's output.
 			var responseBuffer bytes.Buffer
 			// Provide an io.Writer to the handler.
-			s.handler.HandleMessage(&responseBuffer, msgCopy)
+			if handler, ok := s.handler.(ContextMessageHandler); ok {
+				handler.HandleMessageContext(ctx, &responseBuffer, msgCopy)
+			} else {
+				s.handler.HandleMessage(&responseBuffer, msgCopy)
+			}
 			// Write the framed message to the underlying writer.
 			writeMutex.Lock()
 			defer writeMutex.Unlock()
@@ -1,6 +1,7 @@
 package mcpstdio
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -20,6 +21,10 @@ func (c *StdIOJSONRPCClient) Send(reqBytes []byte) ([]byte, error) {
 	return c.client.Send(reqBytes)
 }
 
+func (c *StdIOJSONRPCClient) SendContext(ctx context.Context, reqBytes []byte) ([]byte, error) {
+	return c.client.SendContext(ctx, reqBytes)
+}
+
 func NewStdIOClient(t *testing.T) *StdIOJSONRPCClient {
 	handler := SetupStdIOTransport()
 	clientReader, serverWriter := io.Pipe()
@@ -55,10 +60,22 @@ func TestInvalidSingleRequests(t *testing.T) {
 	helpers_test.TestInvalidSingleRequests(t, getClient(t))
 }
 
+func TestRequestIDEcho(t *testing.T) {
+	helpers_test.TestRequestIDEcho(t, getClient(t))
+}
+
 func TestNotifications(t *testing.T) {
-	helpers_test.TestNotifications(t, getClient(t))
+	helpers_test.TestNotifications(t, getClient(t), false)
 }
 
 func TestBatchRequests(t *testing.T) {
-	helpers_test.TestBatchRequests(t, getClient(t))
+	helpers_test.TestBatchRequests(t, getClient(t), false)
+}
+
+func TestTypedCalls(t *testing.T) {
+	helpers_test.TestTypedCalls(t, NewStdIOClient(t))
+}
+
+func TestPeerInfo(t *testing.T) {
+	helpers_test.TestPeerInfo(t, NewStdIOClient(t), Transport, false)
 }